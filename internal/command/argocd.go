@@ -0,0 +1,191 @@
+/*
+Copyright 2023 GramLabs, Inc.
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package command
+
+import (
+	"os"
+
+	"github.com/spf13/cobra"
+	"github.com/thestormforge/konjure/pkg/konjure"
+	"sigs.k8s.io/kustomize/kyaml/kio"
+	"sigs.k8s.io/kustomize/kyaml/kio/filters"
+	"sigs.k8s.io/kustomize/kyaml/kio/kioutil"
+	"sigs.k8s.io/kustomize/kyaml/yaml"
+)
+
+func NewArgoCDCommand() *cobra.Command {
+	cmd := &cobra.Command{
+		Use:   "argocd",
+		Short: "Run as an Argo CD Config Management Plugin",
+	}
+
+	cmd.AddCommand(
+		newArgoCDInitCommand(),
+		newArgoCDGenerateCommand(),
+		newArgoCDPluginCommand(),
+	)
+
+	return cmd
+}
+
+// argoCDOptions are the options shared by the plugin commands.
+type argoCDOptions struct {
+	konjure.Resources
+	konjure.Filter
+	noCache bool
+}
+
+// addFlags adds the shared flags to the command.
+func (o *argoCDOptions) addFlags(cmd *cobra.Command) {
+	addFilterFlags(cmd, &o.Filter, &o.noCache)
+}
+
+// complete configures the options from the arguments and the Argo CD environment.
+func (o *argoCDOptions) complete(cmd *cobra.Command, args []string) (err error) {
+	o.Context = cmd.Context()
+
+	// Argo CD runs the plugin from the application source path
+	if len(args) > 0 {
+		o.Resources = append(o.Resources, konjure.NewResource(args...))
+	} else {
+		o.Resources = append(o.Resources, konjure.NewResource("."))
+	}
+
+	if err := completeFilter(&o.Filter, o.noCache); err != nil {
+		return err
+	}
+
+	return o.ConfigureArgoCD(os.Environ())
+}
+
+func newArgoCDInitCommand() *cobra.Command {
+	o := &argoCDOptions{}
+
+	cmd := &cobra.Command{
+		Use:     "init [INPUT...]",
+		Short:   "Prepare the application source",
+		Long:    "Expand the application source to populate the cache of remote resources",
+		PreRunE: o.complete,
+		RunE: func(cmd *cobra.Command, args []string) error {
+			// Without a cache there is nothing to prepare
+			if o.CacheDirectory == "" {
+				return nil
+			}
+
+			return kio.Pipeline{
+				Inputs:                []kio.Reader{o.Resources},
+				Filters:               []kio.Filter{&o.Filter},
+				ContinueOnEmptyResult: true,
+			}.Execute()
+		},
+	}
+
+	o.addFlags(cmd)
+
+	return cmd
+}
+
+func newArgoCDGenerateCommand() *cobra.Command {
+	o := &argoCDOptions{}
+
+	cmd := &cobra.Command{
+		Use:     "generate [INPUT...]",
+		Short:   "Generate the application manifests",
+		PreRunE: o.complete,
+		RunE: func(cmd *cobra.Command, args []string) error {
+			return kio.Pipeline{
+				Inputs:  []kio.Reader{o.Resources},
+				Filters: []kio.Filter{&o.Filter},
+				Outputs: []kio.Writer{&konjure.Writer{
+					Writer: cmd.OutOrStdout(),
+					ClearAnnotations: []string{
+						kioutil.PathAnnotation,
+						kioutil.LegacyPathAnnotation,
+						filters.FmtAnnotation,
+					},
+				}},
+				ContinueOnEmptyResult: true,
+			}.Execute()
+		},
+	}
+
+	o.addFlags(cmd)
+
+	return cmd
+}
+
+// argoCDPlugin is the Argo CD ConfigManagementPlugin manifest.
+type argoCDPlugin struct {
+	APIVersion string `yaml:"apiVersion"`
+	Kind       string `yaml:"kind"`
+	Metadata   struct {
+		Name string `yaml:"name"`
+	} `yaml:"metadata"`
+	Spec struct {
+		Version  string              `yaml:"version,omitempty"`
+		Init     argoCDPluginCommand `yaml:"init"`
+		Generate argoCDPluginCommand `yaml:"generate"`
+		Discover struct {
+			FileName string               `yaml:"fileName,omitempty"`
+			Find     *argoCDPluginCommand `yaml:"find,omitempty"`
+		} `yaml:"discover"`
+	} `yaml:"spec"`
+}
+
+// argoCDPluginCommand is a command run by the Config Management Plugin sidecar.
+type argoCDPluginCommand struct {
+	Command []string `yaml:"command"`
+}
+
+func newArgoCDPluginCommand() *cobra.Command {
+	p := &argoCDPlugin{APIVersion: "argoproj.io/v1alpha1", Kind: "ConfigManagementPlugin"}
+	var command string
+	var generateArgs []string
+
+	cmd := &cobra.Command{
+		Use:   "plugin",
+		Short: "Generate the ConfigManagementPlugin manifest",
+		Long:  "Generate the plugin.yaml manifest used to configure a Config Management Plugin sidecar",
+		Args:  cobra.NoArgs,
+		RunE: func(cmd *cobra.Command, args []string) error {
+			p.Spec.Version = cmd.Root().Version
+			p.Spec.Init.Command = append([]string{command, "argocd", "init"}, generateArgs...)
+			p.Spec.Generate.Command = append([]string{command, "argocd", "generate"}, generateArgs...)
+
+			// By default, discover any source containing a Konjure resource
+			if p.Spec.Discover.FileName == "" {
+				p.Spec.Discover.Find = &argoCDPluginCommand{
+					Command: []string{"sh", "-c", "grep -rlE --include='*.yaml' --include='*.yml' '^apiVersion: *konjure.stormforge.io/' ."},
+				}
+			}
+
+			data, err := yaml.Marshal(p)
+			if err != nil {
+				return err
+			}
+			_, err = cmd.OutOrStdout().Write(data)
+			return err
+		},
+	}
+
+	cmd.Flags().StringVar(&p.Metadata.Name, "name", "konjure", "the `name` of the plugin")
+	cmd.Flags().StringVar(&command, "command", "konjure", "the `path` to the Konjure executable in the sidecar")
+	cmd.Flags().StringArrayVar(&generateArgs, "arg", nil, "additional `argument` to pass when generating manifests (can specify multiple)")
+	cmd.Flags().StringVar(&p.Spec.Discover.FileName, "discover-file", "", "discover applications using a file name `glob` instead of searching for Konjure resources")
+
+	return cmd
+}
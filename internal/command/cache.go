@@ -0,0 +1,80 @@
+/*
+Copyright 2023 GramLabs, Inc.
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package command
+
+import (
+	"os"
+	"path/filepath"
+	"time"
+
+	"github.com/spf13/cobra"
+	"github.com/thestormforge/konjure/internal/readers"
+)
+
+func NewCacheCommand() *cobra.Command {
+	cache := &readers.Cache{}
+
+	cmd := &cobra.Command{
+		Use:   "cache",
+		Short: "Manage the cache of remote resources",
+		PersistentPreRun: func(*cobra.Command, []string) {
+			if cache.Dir == "" {
+				cache.Dir = defaultCacheDir()
+			}
+		},
+	}
+
+	cmd.PersistentFlags().StringVar(&cache.Dir, "cache-dir", "", "override the `directory` used to cache remote resources")
+
+	cmd.AddCommand(
+		newCachePruneCommand(cache),
+	)
+
+	return cmd
+}
+
+func newCachePruneCommand(cache *readers.Cache) *cobra.Command {
+	var maxAge time.Duration
+	var all bool
+
+	cmd := &cobra.Command{
+		Use:   "prune",
+		Short: "Remove unused entries from the cache",
+		Args:  cobra.NoArgs,
+		RunE: func(cmd *cobra.Command, args []string) error {
+			before := time.Now().Add(-maxAge)
+			if all {
+				before = time.Now()
+			}
+			return cache.Prune(before)
+		},
+	}
+
+	cmd.Flags().DurationVar(&maxAge, "max-age", 7*24*time.Hour, "remove entries not used within this `duration`")
+	cmd.Flags().BoolVar(&all, "all", false, "remove all entries")
+
+	return cmd
+}
+
+// defaultCacheDir returns the default location of the cache.
+func defaultCacheDir() string {
+	dir, err := os.UserCacheDir()
+	if err != nil {
+		return filepath.Join(os.TempDir(), "konjure")
+	}
+	return filepath.Join(dir, "konjure")
+}
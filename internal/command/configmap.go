@@ -0,0 +1,68 @@
+/*
+Copyright 2023 GramLabs, Inc.
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package command
+
+import (
+	"fmt"
+
+	"github.com/spf13/cobra"
+	"github.com/thestormforge/konjure/internal/readers"
+	konjurev1beta2 "github.com/thestormforge/konjure/pkg/api/core/v1beta2"
+	"github.com/thestormforge/konjure/pkg/filters"
+	"github.com/thestormforge/konjure/pkg/konjure"
+	"sigs.k8s.io/kustomize/kyaml/kio"
+)
+
+func NewConfigMapCommand() *cobra.Command {
+	f := configMapFlags{}
+
+	cmd := &cobra.Command{
+		Use:    "configmap",
+		Short:  "Generate config maps",
+		PreRun: f.preRun,
+		RunE: func(cmd *cobra.Command, args []string) error {
+			return kio.Pipeline{
+				Inputs:  []kio.Reader{&readers.ConfigMapReader{ConfigMap: f.ConfigMap}},
+				Filters: []kio.Filter{&filters.ContentHashFilter{}},
+				Outputs: []kio.Writer{&konjure.Writer{Writer: cmd.OutOrStdout()}},
+			}.Execute()
+		},
+	}
+
+	cmd.Flags().StringVar(&f.ConfigMapName, "name", "", "`name` of the config map to generate")
+	cmd.Flags().BoolVar(&f.AppendHash, "append-hash", false, "append a hash of the contents to the name")
+	cmd.Flags().StringToStringVar(&f.literals, "literal", nil, "literal `name=value` pair")
+	cmd.Flags().StringArrayVar(&f.FileSources, "file", nil, "file, directory or glob `path` to include")
+	cmd.Flags().StringArrayVar(&f.EnvSources, "env", nil, "env `file` to read")
+	cmd.Flags().StringArrayVar(&f.BinaryFileSources, "binary-file", nil, "file `path` to include as binary data")
+	cmd.Flags().BoolVar(&f.Immutable, "immutable", false, "generate an immutable config map")
+	cmd.Flags().StringToStringVar(&f.Labels, "label", nil, "label `name=value` pair")
+	cmd.Flags().StringToStringVar(&f.Annotations, "annotation", nil, "annotation `name=value` pair")
+
+	return cmd
+}
+
+type configMapFlags struct {
+	konjurev1beta2.ConfigMap
+	literals map[string]string
+}
+
+func (f *configMapFlags) preRun(*cobra.Command, []string) {
+	for k, v := range f.literals {
+		f.LiteralSources = append(f.LiteralSources, fmt.Sprintf("%s=%s", k, v))
+	}
+}
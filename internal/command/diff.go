@@ -0,0 +1,114 @@
+/*
+Copyright 2023 GramLabs, Inc.
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package command
+
+import (
+	"errors"
+
+	"github.com/spf13/cobra"
+	"github.com/thestormforge/konjure/pkg/konjure"
+	"sigs.k8s.io/kustomize/kyaml/yaml"
+)
+
+// errDifferences is returned so the process exits with a non-zero status when there are differences.
+var errDifferences = &ExitError{Code: 1, Err: errors.New("resources differ")}
+
+// diffError wraps errors so the process exits with a status that cannot be mistaken for differences.
+func diffError(err error) error {
+	if err == nil {
+		return nil
+	}
+	return &ExitError{Code: 2, Err: err}
+}
+
+func NewDiffCommand() *cobra.Command {
+	f := &konjure.Filter{}
+	var before, after konjure.Resources
+	var output string
+	var noCache bool
+
+	cmd := &cobra.Command{
+		Use:   "diff SOURCE_A... -- SOURCE_B...",
+		Short: "Compare the resources expanded from two sets of inputs",
+		Long: "Compare the resources expanded from two sets of inputs.\n\n" +
+			"Resources are matched by API version, kind, namespace and name. Like diff(1), the exit status is 0 if " +
+			"there are no differences, 1 if there are differences and 2 if there was a problem.",
+		PreRunE: func(cmd *cobra.Command, args []string) error {
+			f.DefaultReader = cmd.InOrStdin()
+			f.Context = cmd.Context()
+
+			switch dash := cmd.ArgsLenAtDash(); {
+			case dash > 0 && dash < len(args):
+				before = konjure.Resources{konjure.NewResource(args[:dash]...)}
+				after = konjure.Resources{konjure.NewResource(args[dash:]...)}
+			case dash < 0 && len(args) == 2:
+				before = konjure.Resources{konjure.NewResource(args[0])}
+				after = konjure.Resources{konjure.NewResource(args[1])}
+			default:
+				return diffError(errors.New("expected inputs to compare separated by --"))
+			}
+
+			return diffError(completeFilter(f, noCache))
+		},
+		RunE: func(cmd *cobra.Command, args []string) error {
+			a, err := expand(f, before)
+			if err != nil {
+				return diffError(err)
+			}
+
+			b, err := expand(f, after)
+			if err != nil {
+				return diffError(err)
+			}
+
+			diffs, err := konjure.Diff(a, b)
+			if err != nil {
+				return diffError(err)
+			}
+
+			if err := konjure.WriteDiff(cmd.OutOrStdout(), output, diffs); err != nil {
+				return diffError(err)
+			}
+
+			if len(diffs) > 0 {
+				cmd.SilenceErrors = true
+				return errDifferences
+			}
+			return nil
+		},
+	}
+
+	cmd.SetFlagErrorFunc(func(cmd *cobra.Command, err error) error { return diffError(err) })
+	cmd.Flags().StringVarP(&output, "output", "o", "text", "set the output format (text, unified, json)")
+	addFilterFlags(cmd, f, &noCache)
+	cmd.Flags().StringVarP(&f.LabelSelector, "selector", "l", "", "label query to filter on")
+	cmd.Flags().StringVar(&f.Kind, "kind", "", "keep only resource matching the specified kind")
+	cmd.Flags().BoolVar(&f.KeepStatus, "keep-status", false, "compare status fields, if present")
+	cmd.Flags().BoolVar(&f.KubernetesExport, "export", false, "remove server populated fields from cluster resources")
+	cmd.Flags().BoolVar(&f.KubernetesRemoveDefaults, "remove-defaults", false, "remove values equal to API defaults from exported cluster resources")
+
+	return cmd
+}
+
+// expand returns the fully expanded resources.
+func expand(f *konjure.Filter, r konjure.Resources) ([]*yaml.RNode, error) {
+	nodes, err := r.Read()
+	if err != nil {
+		return nil, err
+	}
+	return f.Filter(nodes)
+}
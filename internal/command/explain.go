@@ -0,0 +1,76 @@
+/*
+Copyright 2023 GramLabs, Inc.
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package command
+
+import (
+	"encoding/json"
+	"fmt"
+
+	"github.com/spf13/cobra"
+	"github.com/thestormforge/konjure/pkg/konjure"
+	"sigs.k8s.io/kustomize/kyaml/kio"
+)
+
+func NewExplainCommand() *cobra.Command {
+	r := konjure.Resources{}
+	f := &konjure.Filter{Explanation: &konjure.Explanation{}}
+	var output string
+	var noCache bool
+
+	cmd := &cobra.Command{
+		Use:   "explain INPUT...",
+		Short: "Describe how resources are expanded",
+		PreRunE: func(cmd *cobra.Command, args []string) error {
+			f.DefaultReader = cmd.InOrStdin()
+			f.Context = cmd.Context()
+
+			if len(args) > 0 {
+				r = append(r, konjure.NewResource(args...))
+			} else {
+				r = append(r, konjure.NewResource("-"))
+			}
+
+			return completeFilter(f, noCache)
+		},
+		RunE: func(cmd *cobra.Command, args []string) error {
+			// The resources are discarded, only the explanation is written
+			if err := (kio.Pipeline{
+				Inputs:                []kio.Reader{r},
+				Filters:               []kio.Filter{f},
+				ContinueOnEmptyResult: true,
+			}).Execute(); err != nil {
+				return err
+			}
+
+			switch output {
+			case "tree", "":
+				return f.Explanation.WriteTree(cmd.OutOrStdout())
+			case "json":
+				enc := json.NewEncoder(cmd.OutOrStdout())
+				enc.SetIndent("", "  ")
+				return enc.Encode(f.Explanation)
+			default:
+				return fmt.Errorf("unknown format: %s", output)
+			}
+		},
+	}
+
+	cmd.Flags().StringVarP(&output, "output", "o", "tree", "set the output format (tree, json)")
+	addFilterFlags(cmd, f, &noCache)
+
+	return cmd
+}
@@ -0,0 +1,70 @@
+/*
+Copyright 2023 GramLabs, Inc.
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package command
+
+import (
+	"os"
+	"path/filepath"
+
+	"github.com/spf13/cobra"
+	"github.com/thestormforge/konjure/internal/readers"
+	"github.com/thestormforge/konjure/pkg/konjure"
+)
+
+// addFilterFlags adds the flags used to configure the expansion of resources.
+func addFilterFlags(cmd *cobra.Command, f *konjure.Filter, noCache *bool) {
+	cmd.Flags().IntVarP(&f.Depth, "depth", "d", 100, "limit the number of times expansion can happen")
+	cmd.Flags().IntVar(&f.Concurrency, "parallel", 1, "limit the number of resources expanded concurrently")
+	cmd.Flags().DurationVar(&f.Timeout, "timeout", 0, "limit the amount of time spent expanding resources (e.g. 5m), zero for no limit")
+	cmd.Flags().BoolVarP(&f.RecursiveDirectories, "recurse", "r", false, "recursively process directories")
+	cmd.Flags().StringVar(&f.Kubeconfig, "kubeconfig", "", "path to the kubeconfig file")
+	cmd.Flags().StringVar(&f.CacheDirectory, "cache-dir", "", "override the `directory` used to cache remote resources (defaults to the user cache directory)")
+	cmd.Flags().BoolVar(noCache, "no-cache", false, "do not cache remote resources (remote resources are cached by default)")
+
+	// Commands that write the lock file define their own flag
+	if cmd.Flags().Lookup("lock-file") == nil {
+		cmd.Flags().StringVar(&f.LockFile, "lock-file", "", "verify remote resources against the lock `file` (defaults to "+readers.LockFile+", if present)")
+	}
+}
+
+// completeFilter fills in the defaults that depend on the environment.
+func completeFilter(f *konjure.Filter, noCache bool) (err error) {
+	if f.WorkingDirectory == "" {
+		f.WorkingDirectory, err = os.Getwd()
+		if err != nil {
+			return err
+		}
+	}
+
+	if f.LockFile == "" {
+		if _, err := os.Stat(filepath.Join(f.WorkingDirectory, readers.LockFile)); err == nil {
+			f.LockFile = filepath.Join(f.WorkingDirectory, readers.LockFile)
+		}
+	}
+
+	if f.GitToken == "" {
+		f.GitToken = os.Getenv("KONJURE_GIT_TOKEN")
+	}
+
+	if noCache {
+		f.CacheDirectory = ""
+	} else if f.CacheDirectory == "" {
+		f.CacheDirectory = defaultCacheDir()
+	}
+
+	return nil
+}
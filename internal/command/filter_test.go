@@ -0,0 +1,63 @@
+/*
+Copyright 2023 GramLabs, Inc.
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package command
+
+import (
+	"testing"
+
+	"github.com/spf13/cobra"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+	"github.com/thestormforge/konjure/pkg/konjure"
+)
+
+func TestCompleteFilter_cache(t *testing.T) {
+	cases := []struct {
+		desc     string
+		args     []string
+		expected string
+	}{
+		{
+			desc:     "default",
+			expected: defaultCacheDir(),
+		},
+		{
+			desc:     "cache dir",
+			args:     []string{"--cache-dir", "/tmp/konjure-test"},
+			expected: "/tmp/konjure-test",
+		},
+		{
+			desc: "no cache",
+			args: []string{"--no-cache"},
+		},
+		{
+			desc: "no cache overrides cache dir",
+			args: []string{"--cache-dir", "/tmp/konjure-test", "--no-cache"},
+		},
+	}
+	for _, c := range cases {
+		t.Run(c.desc, func(t *testing.T) {
+			f := &konjure.Filter{WorkingDirectory: t.TempDir()}
+			var noCache bool
+			cmd := &cobra.Command{}
+			addFilterFlags(cmd, f, &noCache)
+			require.NoError(t, cmd.ParseFlags(c.args))
+			require.NoError(t, completeFilter(f, noCache))
+			assert.Equal(t, c.expected, f.CacheDirectory)
+		})
+	}
+}
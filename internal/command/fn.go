@@ -0,0 +1,53 @@
+/*
+Copyright 2023 GramLabs, Inc.
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package command
+
+import (
+	"github.com/spf13/cobra"
+	"github.com/thestormforge/konjure/pkg/konjure"
+	"sigs.k8s.io/kustomize/kyaml/fn/framework"
+	"sigs.k8s.io/kustomize/kyaml/kio"
+)
+
+func NewFunctionCommand() *cobra.Command {
+	fn := &konjure.Function{}
+	var noCache bool
+
+	cmd := &cobra.Command{
+		Use:   "fn",
+		Short: "Run as a KRM function",
+		Long:  "Expand the Konjure resources in a KRM function ResourceList read from stdin",
+		Args:  cobra.NoArgs,
+		PreRunE: func(cmd *cobra.Command, args []string) error {
+			fn.Context = cmd.Context()
+
+			return completeFilter(&fn.Filter, noCache)
+		},
+		RunE: func(cmd *cobra.Command, args []string) error {
+			return framework.Execute(fn, &kio.ByteReadWriter{
+				Reader:                cmd.InOrStdin(),
+				Writer:                cmd.OutOrStdout(),
+				KeepReaderAnnotations: true,
+			})
+		},
+	}
+
+	addFilterFlags(cmd, &fn.Filter, &noCache)
+	cmd.Flags().BoolVar(&fn.KeepReaderAnnotations, "keep-annotations", false, "retain annotations used for processing")
+
+	return cmd
+}
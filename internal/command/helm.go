@@ -46,6 +46,7 @@ func NewHelmCommand() *cobra.Command {
 	cmd.Flags().StringVarP(&f.Helm.ReleaseNamespace, "namespace", "n", "default", "release `namespace`")
 	cmd.Flags().StringVar(&f.Helm.Version, "version", "", "fetch a specific `version` of a chart; if empty, the latest version of the chart will be used")
 	cmd.Flags().StringVar(&f.RepositoryCache, "repository-cache", "", "override the `directory` of your cached Helm repository index")
+	cmd.Flags().StringVar(&f.RegistryConfig, "registry-config", "", "override the `path` to the registry config file")
 	cmd.Flags().StringToStringVar(&f.set, "set", nil, "set `value`s on the command line")
 	cmd.Flags().StringToStringVar(&f.setFile, "set-file", nil, "set values from `file`s on the command line")
 	cmd.Flags().StringToStringVar(&f.setString, "set-string", nil, "set string `value`s on the command line")
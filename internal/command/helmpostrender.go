@@ -0,0 +1,143 @@
+/*
+Copyright 2023 GramLabs, Inc.
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package command
+
+import (
+	"bytes"
+	"os"
+	"path/filepath"
+
+	"github.com/spf13/cobra"
+	konjurev1beta2 "github.com/thestormforge/konjure/pkg/api/core/v1beta2"
+	"github.com/thestormforge/konjure/pkg/filters"
+	"github.com/thestormforge/konjure/pkg/konjure"
+	"sigs.k8s.io/kustomize/kyaml/yaml"
+)
+
+// postRenderConfig is the configuration file format for the Helm post-renderer.
+type postRenderConfig struct {
+	// Filter to determine which resources are retained.
+	filters.ResourceMetaFilter `json:",inline" yaml:",inline"`
+	// Flag indicating that status fields should not be stripped.
+	KeepStatus bool `json:"keepStatus,omitempty" yaml:"keepStatus,omitempty"`
+	// Flag indicating that comments should not be stripped, defaults to true.
+	KeepComments *bool `json:"keepComments,omitempty" yaml:"keepComments,omitempty"`
+	// Flag indicating that output should be formatted.
+	Format bool `json:"format,omitempty" yaml:"format,omitempty"`
+	// Flag indicating that output should be sorted.
+	Sort bool `json:"sort,omitempty" yaml:"sort,omitempty"`
+	// Flag indicating the output should be transformed to application definitions.
+	Apps bool `json:"apps,omitempty" yaml:"apps,omitempty"`
+	// The labels used to determine application names.
+	ApplicationNameLabels []string `json:"applicationNameLabels,omitempty" yaml:"applicationNameLabels,omitempty"`
+	// Flag indicating that only workload resources should be kept.
+	Workloads bool `json:"workloads,omitempty" yaml:"workloads,omitempty"`
+	// Additional Konjure resources to include with the rendered manifests.
+	Resources []string `json:"resources,omitempty" yaml:"resources,omitempty"`
+	// Patches to apply to the rendered manifests.
+	Patches []konjurev1beta2.PatchSpec `json:"patches,omitempty" yaml:"patches,omitempty"`
+	// Common transformations to apply to the rendered manifests.
+	Transform filters.TransformFilter `json:"transform,omitempty" yaml:"transform,omitempty"`
+}
+
+// readPostRenderConfig loads the post-renderer configuration file.
+func readPostRenderConfig(filename string) (*postRenderConfig, error) {
+	data, err := os.ReadFile(filename)
+	if err != nil {
+		return nil, err
+	}
+
+	cfg := &postRenderConfig{}
+	if err := yaml.Unmarshal(data, cfg); err != nil {
+		return nil, err
+	}
+	return cfg, nil
+}
+
+// configure applies the configuration to the post-renderer.
+func (cfg *postRenderConfig) configure(pr *konjure.PostRenderer) {
+	pr.Filter.ResourceMetaFilter = cfg.ResourceMetaFilter
+	pr.Filter.KeepStatus = cfg.KeepStatus
+	if cfg.KeepComments != nil {
+		pr.Filter.KeepComments = *cfg.KeepComments
+	}
+	pr.Filter.Format = cfg.Format
+	pr.Filter.ApplicationFilter.Enabled = cfg.Apps
+	pr.Filter.ApplicationFilter.ApplicationNameLabels = cfg.ApplicationNameLabels
+	pr.Filter.WorkloadFilter.Enabled = cfg.Workloads
+	pr.Filter.Patches = cfg.Patches
+	pr.Filter.TransformFilter = cfg.Transform
+	pr.Writer.Sort = cfg.Sort
+	if len(cfg.Resources) > 0 {
+		pr.Resources = append(pr.Resources, konjure.NewResource(cfg.Resources...))
+	}
+}
+
+func NewHelmPostRenderCommand() *cobra.Command {
+	pr := &konjure.PostRenderer{}
+	var config string
+	var noCache bool
+
+	cmd := &cobra.Command{
+		Use:   "helm-post-render",
+		Short: "Run as a Helm post-renderer",
+		Long:  "Filter the rendered manifests Helm supplies on stdin, writing the modified manifests to stdout",
+		Args:  cobra.NoArgs,
+		PreRunE: func(cmd *cobra.Command, args []string) (err error) {
+			pr.Filter.Context = cmd.Context()
+			pr.Filter.KeepComments = true
+
+			if config != "" {
+				cfg, err := readPostRenderConfig(config)
+				if err != nil {
+					return err
+				}
+				cfg.configure(pr)
+
+				// Relative resources in the configuration file are resolved against its location
+				pr.Filter.WorkingDirectory = filepath.Dir(config)
+				if !filepath.IsAbs(pr.Filter.WorkingDirectory) {
+					pr.Filter.WorkingDirectory, err = filepath.Abs(pr.Filter.WorkingDirectory)
+					if err != nil {
+						return err
+					}
+				}
+			}
+
+			return completeFilter(&pr.Filter, noCache)
+		},
+		RunE: func(cmd *cobra.Command, args []string) error {
+			in := &bytes.Buffer{}
+			if _, err := in.ReadFrom(cmd.InOrStdin()); err != nil {
+				return err
+			}
+
+			out, err := pr.Run(in)
+			if err != nil {
+				return err
+			}
+
+			_, err = out.WriteTo(cmd.OutOrStdout())
+			return err
+		},
+	}
+
+	cmd.Flags().StringVarP(&config, "config", "c", "", "the configuration `file` of filters to apply")
+	addFilterFlags(cmd, &pr.Filter, &noCache)
+
+	return cmd
+}
@@ -0,0 +1,60 @@
+/*
+Copyright 2023 GramLabs, Inc.
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package command
+
+import (
+	"github.com/spf13/cobra"
+	"github.com/thestormforge/konjure/internal/readers"
+	"github.com/thestormforge/konjure/pkg/konjure"
+	"sigs.k8s.io/kustomize/kyaml/kio"
+)
+
+func NewLockCommand() *cobra.Command {
+	r := konjure.Resources{}
+	f := &konjure.Filter{UpdateLock: true}
+	var noCache bool
+
+	cmd := &cobra.Command{
+		Use:   "lock INPUT...",
+		Short: "Pin remote resources to immutable versions",
+		PreRunE: func(cmd *cobra.Command, args []string) error {
+			f.DefaultReader = cmd.InOrStdin()
+			f.Context = cmd.Context()
+
+			if len(args) > 0 {
+				r = append(r, konjure.NewResource(args...))
+			} else {
+				r = append(r, konjure.NewResource("-"))
+			}
+
+			return completeFilter(f, noCache)
+		},
+		RunE: func(cmd *cobra.Command, args []string) error {
+			// There are no outputs, the lock file is written by the filter
+			return kio.Pipeline{
+				Inputs:                []kio.Reader{r},
+				Filters:               []kio.Filter{f},
+				ContinueOnEmptyResult: true,
+			}.Execute()
+		},
+	}
+
+	cmd.Flags().StringVar(&f.LockFile, "lock-file", readers.LockFile, "the lock `file` to write")
+	addFilterFlags(cmd, f, &noCache)
+
+	return cmd
+}
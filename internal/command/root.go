@@ -17,12 +17,14 @@ limitations under the License.
 package command
 
 import (
-	"os"
+	"fmt"
+	"strings"
 
 	"github.com/spf13/cobra"
+	"github.com/thestormforge/konjure/pkg/filters"
 	"github.com/thestormforge/konjure/pkg/konjure"
 	"sigs.k8s.io/kustomize/kyaml/kio"
-	"sigs.k8s.io/kustomize/kyaml/kio/filters"
+	kiofilters "sigs.k8s.io/kustomize/kyaml/kio/filters"
 	"sigs.k8s.io/kustomize/kyaml/kio/kioutil"
 )
 
@@ -30,6 +32,9 @@ func NewRootCommand(version, refspec, date string) *cobra.Command {
 	r := konjure.Resources{}
 	f := &konjure.Filter{}
 	w := &konjure.Writer{}
+	var noCache, watch bool
+	var outputDir string
+	var schemas, policies []string
 
 	cmd := &cobra.Command{
 		Use:              "konjure INPUT...",
@@ -44,6 +49,7 @@ func NewRootCommand(version, refspec, date string) *cobra.Command {
 		PreRunE: func(cmd *cobra.Command, args []string) (err error) {
 			w.Writer = cmd.OutOrStdout()
 			f.DefaultReader = cmd.InOrStdin()
+			f.Context = cmd.Context()
 
 			if len(args) > 0 {
 				r = append(r, konjure.NewResource(args...))
@@ -51,19 +57,60 @@ func NewRootCommand(version, refspec, date string) *cobra.Command {
 				r = append(r, konjure.NewResource("-"))
 			}
 
-			f.WorkingDirectory, err = os.Getwd()
+			if len(policies) > 0 {
+				r = append(r, konjure.NewResource(policies...))
+				f.PolicyFilter.Report = cmd.ErrOrStderr()
+			}
+
+			if outputDir != "" && !watch {
+				return fmt.Errorf("--output-dir can only be used with --watch")
+			}
+
+			if err := completeFilter(f, noCache); err != nil {
+				return err
+			}
+
+			if len(schemas) > 0 {
+				sf := &konjure.Filter{
+					Depth:            f.Depth,
+					WorkingDirectory: f.WorkingDirectory,
+					CacheDirectory:   f.CacheDirectory,
+					LockFile:         f.LockFile,
+					GitToken:         f.GitToken,
+					Context:          f.Context,
+				}
+				nodes, err := konjure.Resources{konjure.NewResource(schemas...)}.Read()
+				if err != nil {
+					return err
+				}
+				f.ValidationFilter.CustomResourceDefinitions, err = sf.Filter(nodes)
+				if err != nil {
+					return err
+				}
+			}
 
 			if !w.KeepReaderAnnotations {
 				w.ClearAnnotations = append(w.ClearAnnotations,
 					kioutil.PathAnnotation,
 					kioutil.LegacyPathAnnotation,
-					filters.FmtAnnotation,
+					kiofilters.FmtAnnotation,
 				)
 			}
 
 			return
 		},
 		RunE: func(cmd *cobra.Command, args []string) error {
+			if watch {
+				wt := &konjure.Watcher{
+					Filter:    *f,
+					Writer:    *w,
+					Resources: r,
+					Directory: outputDir,
+					Log:       cmd.ErrOrStderr(),
+				}
+				return wt.Watch(cmd.Context())
+			}
+
 			return kio.Pipeline{
 				Inputs:                []kio.Reader{r},
 				Filters:               []kio.Filter{f},
@@ -73,18 +120,30 @@ func NewRootCommand(version, refspec, date string) *cobra.Command {
 		},
 	}
 
-	cmd.Flags().IntVarP(&f.Depth, "depth", "d", 100, "limit the number of times expansion can happen")
+	addFilterFlags(cmd, f, &noCache)
 	cmd.Flags().StringVarP(&f.LabelSelector, "selector", "l", "", "label query to filter on")
 	cmd.Flags().StringVar(&f.Kind, "kind", "", "keep only resource matching the specified kind")
 	cmd.Flags().BoolVar(&f.KeepStatus, "keep-status", false, "retain status fields, if present")
 	cmd.Flags().BoolVar(&f.KeepComments, "keep-comments", true, "retain YAML comments")
 	cmd.Flags().BoolVar(&f.Format, "format", false, "format output to Kubernetes conventions")
 	cmd.Flags().BoolVar(&w.RestoreVerticalWhiteSpace, "vws", false, "attempt to restore vertical white space")
-	cmd.Flags().BoolVarP(&f.RecursiveDirectories, "recurse", "r", false, "recursively process directories")
-	cmd.Flags().StringVar(&f.Kubeconfig, "kubeconfig", "", "path to the kubeconfig file")
-	cmd.Flags().StringVarP(&w.Format, "output", "o", "yaml", "set the output format (yaml, json, ndjson, env, name, columns=, csv=, template=)")
+	cmd.Flags().BoolVar(&f.KubernetesExport, "export", false, "remove server populated fields from cluster resources")
+	cmd.Flags().BoolVar(&f.KubernetesRemoveDefaults, "remove-defaults", false, "remove values equal to API defaults from exported cluster resources")
+	cmd.Flags().StringVarP(&w.Format, "output", "o", "yaml", "set the output format (yaml, json, ndjson, env, name, provenance, columns=, csv=, template=)")
 	cmd.Flags().BoolVar(&w.KeepReaderAnnotations, "keep-annotations", false, "retain annotations used for processing")
 	cmd.Flags().BoolVar(&w.Sort, "sort", false, "sort output prior to writing")
+	cmd.Flags().StringVar(&f.TransformFilter.Namespace, "namespace", "", "set the `namespace` of every namespaced resource")
+	cmd.Flags().StringVar(&f.TransformFilter.NamePrefix, "name-prefix", "", "add a `prefix` to the name of every resource")
+	cmd.Flags().StringVar(&f.TransformFilter.NameSuffix, "name-suffix", "", "add a `suffix` to the name of every resource")
+	cmd.Flags().StringToStringVar(&f.TransformFilter.CommonLabels, "common-labels", nil, "add labels to every resource, selector and pod template")
+	cmd.Flags().StringToStringVar(&f.TransformFilter.CommonAnnotations, "common-annotations", nil, "add annotations to every resource and pod template")
+	cmd.Flags().StringArrayVar(&f.TransformFilter.Images, "set-image", nil, "override container images using `name=ref` (a ref starting with : or @ only changes the tag or digest)")
+	cmd.Flags().BoolVar(&f.ValidationFilter.Enabled, "validate", false, "check resources against the schemas for their types")
+	cmd.Flags().StringVar(&f.ValidationFilter.KubernetesVersion, "kubernetes-version", "", "the `version` of the bundled Kubernetes schemas used for validation (available: "+strings.Join(filters.KubernetesVersions(), ", ")+")")
+	cmd.Flags().StringSliceVar(&schemas, "schema", nil, "additional `input` containing CustomResourceDefinitions used for validation")
+	cmd.Flags().StringSliceVar(&policies, "policy", nil, "check resources against the policies in `file`")
+	cmd.Flags().BoolVarP(&watch, "watch", "w", false, "expand again whenever the local files used change")
+	cmd.Flags().StringVar(&outputDir, "output-dir", "", "when watching, write each resource to a separate file in `directory`")
 	cmd.Flags().BoolVar(&f.ApplicationFilter.Enabled, "apps", false, "transform output to application definitions")
 	cmd.Flags().StringSliceVar(&f.ApplicationFilter.ApplicationNameLabels, "application-name-label", nil, "label to use for application names")
 	cmd.Flags().BoolVar(&f.WorkloadFilter.Enabled, "workloads", false, "keep only workload resources")
@@ -99,7 +158,26 @@ func NewRootCommand(version, refspec, date string) *cobra.Command {
 		NewHelmValuesCommand(),
 		NewJsonnetCommand(),
 		NewSecretCommand(),
+		NewConfigMapCommand(),
+		NewCacheCommand(),
+		NewLockCommand(),
+		NewExplainCommand(),
+		NewDiffCommand(),
+		NewFunctionCommand(),
+		NewHelmPostRenderCommand(),
+		NewArgoCDCommand(),
 	)
 
 	return cmd
 }
+
+// ExitError is an error that should cause the process to exit with a specific status.
+type ExitError struct {
+	// The exit status.
+	Code int
+	// The cause of the exit.
+	Err error
+}
+
+func (e *ExitError) Error() string { return e.Err.Error() }
+func (e *ExitError) Unwrap() error { return e.Err }
@@ -24,6 +24,7 @@ import (
 	"github.com/spf13/cobra"
 	"github.com/thestormforge/konjure/internal/readers"
 	konjurev1beta2 "github.com/thestormforge/konjure/pkg/api/core/v1beta2"
+	"github.com/thestormforge/konjure/pkg/filters"
 	"github.com/thestormforge/konjure/pkg/konjure"
 	"sigs.k8s.io/kustomize/kyaml/kio"
 )
@@ -38,12 +39,14 @@ func NewSecretCommand() *cobra.Command {
 		RunE: func(cmd *cobra.Command, args []string) error {
 			return kio.Pipeline{
 				Inputs:  []kio.Reader{&readers.SecretReader{Secret: f.Secret}},
+				Filters: []kio.Filter{&filters.ContentHashFilter{}},
 				Outputs: []kio.Writer{&konjure.Writer{Writer: cmd.OutOrStdout()}},
 			}.Execute()
 		},
 	}
 
 	cmd.Flags().StringVar(&f.SecretName, "name", "", "`name` of the secret to generate")
+	cmd.Flags().BoolVar(&f.AppendHash, "append-hash", false, "append a hash of the contents to the name")
 	cmd.Flags().StringToStringVar(&f.literals, "literal", nil, "literal `name=value` pair")
 	cmd.Flags().StringArrayVar(&f.FileSources, "file", nil, "file `path` to include")
 	cmd.Flags().StringArrayVar(&f.EnvSources, "env", nil, "env `file` to read")
@@ -0,0 +1,186 @@
+/*
+Copyright 2023 GramLabs, Inc.
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package readers
+
+import (
+	"bytes"
+	"crypto/sha256"
+	"encoding/hex"
+	"errors"
+	"fmt"
+	"io/fs"
+	"os"
+	"path/filepath"
+	"time"
+
+	"sigs.k8s.io/kustomize/kyaml/kio"
+	"sigs.k8s.io/kustomize/kyaml/yaml"
+)
+
+// Cache is a content-addressed store for the results of expensive expansions
+// (e.g. Git checkouts or Helm chart renderings) that can be shared across runs.
+// A nil cache is valid and never contains any entries.
+type Cache struct {
+	// The directory where cache entries are stored.
+	Dir string
+}
+
+// Key returns a cache key computed from the supplied values. Each value is
+// encoded as YAML so the key reflects the full structure of the resource.
+func (c *Cache) Key(values ...interface{}) (string, error) {
+	h := sha256.New()
+	for _, v := range values {
+		data, err := yaml.Marshal(v)
+		if err != nil {
+			return "", err
+		}
+		_, _ = h.Write(data)
+		_, _ = h.Write([]byte{0})
+	}
+	return hex.EncodeToString(h.Sum(nil)), nil
+}
+
+// Path returns the location of a cache entry for the supplied kind and key.
+func (c *Cache) Path(kind, key string) string {
+	return filepath.Join(c.Dir, kind, key)
+}
+
+// Get returns the cached resource nodes for the supplied kind and key. If the
+// cache does not contain an entry, the result will be nil.
+func (c *Cache) Get(kind, key string) ([]*yaml.RNode, error) {
+	if c == nil || key == "" {
+		return nil, nil
+	}
+
+	path := c.Path(kind, key) + ".yaml"
+	data, err := os.ReadFile(path)
+	if errors.Is(err, fs.ErrNotExist) {
+		return nil, nil
+	} else if err != nil {
+		return nil, err
+	}
+
+	// Record the access so recently used entries survive a prune
+	now := time.Now()
+	_ = os.Chtimes(path, now, now)
+
+	nodes, err := (&kio.ByteReader{Reader: bytes.NewReader(data), OmitReaderAnnotations: true}).Read()
+	if err != nil {
+		return nil, fmt.Errorf("invalid cache entry %s: %w", path, err)
+	}
+	return nodes, nil
+}
+
+// Put stores the supplied resource nodes in the cache.
+func (c *Cache) Put(kind, key string, nodes []*yaml.RNode) error {
+	if c == nil || key == "" {
+		return nil
+	}
+
+	var buf bytes.Buffer
+	if err := (kio.ByteWriter{Writer: &buf, KeepReaderAnnotations: true}).Write(nodes); err != nil {
+		return err
+	}
+
+	return c.writeFile(c.Path(kind, key)+".yaml", buf.Bytes())
+}
+
+// GetTag returns an opaque tag value stored for the supplied kind and key (for
+// example, the HTTP ETag associated with a URL).
+func (c *Cache) GetTag(kind, key string) string {
+	if c == nil || key == "" {
+		return ""
+	}
+
+	data, err := os.ReadFile(c.Path(kind, key) + ".tag")
+	if err != nil {
+		return ""
+	}
+	return string(data)
+}
+
+// PutTag stores an opaque tag value for the supplied kind and key.
+func (c *Cache) PutTag(kind, key, tag string) error {
+	if c == nil || key == "" {
+		return nil
+	}
+
+	return c.writeFile(c.Path(kind, key)+".tag", []byte(tag))
+}
+
+// Prune removes all cache entries that have not been used since the supplied time.
+func (c *Cache) Prune(before time.Time) error {
+	if c == nil || c.Dir == "" {
+		return nil
+	}
+
+	kinds, err := os.ReadDir(c.Dir)
+	if errors.Is(err, fs.ErrNotExist) {
+		return nil
+	} else if err != nil {
+		return err
+	}
+
+	for _, kind := range kinds {
+		if !kind.IsDir() {
+			continue
+		}
+
+		entries, err := os.ReadDir(filepath.Join(c.Dir, kind.Name()))
+		if err != nil {
+			return err
+		}
+
+		for _, entry := range entries {
+			info, err := entry.Info()
+			if err != nil {
+				return err
+			}
+			if info.ModTime().After(before) {
+				continue
+			}
+			if err := os.RemoveAll(filepath.Join(c.Dir, kind.Name(), entry.Name())); err != nil {
+				return err
+			}
+		}
+	}
+
+	return nil
+}
+
+// writeFile atomically writes a file into the cache.
+func (c *Cache) writeFile(path string, data []byte) error {
+	if err := os.MkdirAll(filepath.Dir(path), 0700); err != nil {
+		return err
+	}
+
+	f, err := os.CreateTemp(filepath.Dir(path), ".tmp-")
+	if err != nil {
+		return err
+	}
+	defer os.Remove(f.Name())
+
+	if _, err := f.Write(data); err != nil {
+		_ = f.Close()
+		return err
+	}
+	if err := f.Close(); err != nil {
+		return err
+	}
+
+	return os.Rename(f.Name(), path)
+}
@@ -0,0 +1,82 @@
+/*
+Copyright 2023 GramLabs, Inc.
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package readers
+
+import (
+	"net/http"
+	"net/http/httptest"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+	konjurev1beta2 "github.com/thestormforge/konjure/pkg/api/core/v1beta2"
+	"sigs.k8s.io/kustomize/kyaml/kio"
+	"sigs.k8s.io/kustomize/kyaml/yaml"
+)
+
+func TestCache(t *testing.T) {
+	c := &Cache{Dir: t.TempDir()}
+
+	key, err := c.Key(&konjurev1beta2.HTTP{URL: "https://example.com/test.yaml"}, "etag")
+	require.NoError(t, err)
+
+	nodes, err := c.Get("test", key)
+	require.NoError(t, err)
+	assert.Nil(t, nodes, "unexpected cache hit")
+
+	require.NoError(t, c.Put("test", key, []*yaml.RNode{yaml.MustParse("apiVersion: v1\nkind: ConfigMap\nmetadata:\n  name: test\n")}))
+
+	nodes, err = c.Get("test", key)
+	require.NoError(t, err)
+	if assert.Len(t, nodes, 1) {
+		assert.Equal(t, "test", nodes[0].GetName())
+	}
+
+	require.NoError(t, c.Prune(time.Now().Add(time.Hour)))
+
+	nodes, err = c.Get("test", key)
+	require.NoError(t, err)
+	assert.Nil(t, nodes, "expected prune to remove entry")
+}
+
+func TestHTTPReader_Cache(t *testing.T) {
+	var requests, notModified int
+	srv := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		requests++
+		if r.Header.Get("If-None-Match") == `"v1"` {
+			notModified++
+			w.WriteHeader(http.StatusNotModified)
+			return
+		}
+		w.Header().Set("ETag", `"v1"`)
+		_, _ = w.Write([]byte("apiVersion: v1\nkind: ConfigMap\nmetadata:\n  name: test\n"))
+	}))
+	defer srv.Close()
+
+	c := &Cache{Dir: t.TempDir()}
+	for i := 0; i < 2; i++ {
+		nodes, err := (&HTTPReader{HTTP: konjurev1beta2.HTTP{URL: srv.URL}, Cache: c}).Read()
+		require.NoError(t, err)
+		actual, err := kio.StringAll(nodes)
+		require.NoError(t, err)
+		assert.Contains(t, actual, "name: test")
+	}
+
+	assert.Equal(t, 2, requests)
+	assert.Equal(t, 1, notModified)
+}
@@ -0,0 +1,134 @@
+/*
+Copyright 2023 GramLabs, Inc.
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package readers
+
+import (
+	"encoding/base64"
+
+	konjurev1beta2 "github.com/thestormforge/konjure/pkg/api/core/v1beta2"
+	"github.com/thestormforge/konjure/pkg/filters"
+	"sigs.k8s.io/kustomize/kyaml/yaml"
+)
+
+type ConfigMapReader struct {
+	konjurev1beta2.ConfigMap
+
+	// Optional tracker used to record the files that were read.
+	Tracker *Tracker
+}
+
+func (r *ConfigMapReader) Read() ([]*yaml.RNode, error) {
+	// Build the basic config map node
+	n, err := yaml.FromMap(map[string]interface{}{"apiVersion": "v1", "kind": "ConfigMap"})
+	if err != nil {
+		return nil, err
+	}
+	if err := n.PipeE(yaml.SetK8sName(r.ConfigMapName)); err != nil {
+		return nil, err
+	}
+	if len(r.Labels) > 0 {
+		if err := n.SetLabels(r.Labels); err != nil {
+			return nil, err
+		}
+	}
+	annotations := make(map[string]string, len(r.Annotations)+1)
+	for k, v := range r.Annotations {
+		annotations[k] = v
+	}
+	if r.AppendHash {
+		annotations[filters.AppendHashAnnotation] = "true"
+	}
+	if len(annotations) > 0 {
+		if err := n.SetAnnotations(annotations); err != nil {
+			return nil, err
+		}
+	}
+
+	// Add all the config map data
+	if err := n.PipeE(yaml.Tee(
+		yaml.FilterFunc(r.literals),
+		yaml.FilterFunc(r.files),
+		yaml.FilterFunc(r.envs),
+		yaml.FilterFunc(r.binaryFiles),
+	)); err != nil {
+		return nil, err
+	}
+	if r.Immutable {
+		if err := n.PipeE(yaml.SetField("immutable", yaml.NewRNode(&yaml.Node{Kind: yaml.ScalarNode, Tag: yaml.NodeTagBool, Value: "true"}))); err != nil {
+			return nil, err
+		}
+	}
+
+	return []*yaml.RNode{n}, nil
+}
+
+func (r *ConfigMapReader) literals(n *yaml.RNode) (*yaml.RNode, error) {
+	if len(r.LiteralSources) == 0 {
+		return n, nil
+	}
+
+	m, err := literalSources(r.LiteralSources)
+	if err != nil {
+		return nil, err
+	}
+
+	return n, n.LoadMapIntoConfigMapData(m)
+}
+
+func (r *ConfigMapReader) files(n *yaml.RNode) (*yaml.RNode, error) {
+	if len(r.FileSources) == 0 {
+		return n, nil
+	}
+
+	m, err := fileSources(r.FileSources, r.Tracker)
+	if err != nil {
+		return nil, err
+	}
+
+	return n, n.LoadMapIntoConfigMapData(m)
+}
+
+func (r *ConfigMapReader) envs(n *yaml.RNode) (*yaml.RNode, error) {
+	if len(r.EnvSources) == 0 {
+		return n, nil
+	}
+
+	m, err := envSources(r.EnvSources, r.Tracker)
+	if err != nil {
+		return nil, err
+	}
+
+	return n, n.LoadMapIntoConfigMapData(m)
+}
+
+func (r *ConfigMapReader) binaryFiles(n *yaml.RNode) (*yaml.RNode, error) {
+	if len(r.BinaryFileSources) == 0 {
+		return n, nil
+	}
+
+	m, err := fileSources(r.BinaryFileSources, r.Tracker)
+	if err != nil {
+		return nil, err
+	}
+
+	// Binary data is only encoded automatically if it is not valid UTF-8
+	for k, v := range m {
+		m[k] = base64.StdEncoding.EncodeToString([]byte(v))
+	}
+
+	return n, n.LoadMapIntoConfigMapBinaryData(m)
+}
@@ -0,0 +1,80 @@
+/*
+Copyright 2023 GramLabs, Inc.
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package readers
+
+import (
+	"path/filepath"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+	konjurev1beta2 "github.com/thestormforge/konjure/pkg/api/core/v1beta2"
+)
+
+func TestConfigMapReader_Read(t *testing.T) {
+	dir := t.TempDir()
+	writeFiles(t, dir, map[string]string{
+		"conf/app.properties": "a=1\n",
+		"conf/logo.png":       "\x89PNG\xff",
+		"other/x.yaml":        "x: 1\n",
+		"other/y.yaml":        "y: 2\n",
+		"other/z.txt":         "z",
+		"raw.txt":             "raw",
+	})
+
+	r := &ConfigMapReader{
+		ConfigMap: konjurev1beta2.ConfigMap{
+			ConfigMapName: "test",
+			LiteralSources: []string{
+				"literal=value",
+			},
+			FileSources: []string{
+				filepath.Join(dir, "conf"),
+				filepath.Join(dir, "other", "*.yaml"),
+			},
+			BinaryFileSources: []string{
+				"raw=" + filepath.Join(dir, "raw.txt"),
+			},
+			Immutable: true,
+			Labels:    map[string]string{"app": "test"},
+		},
+	}
+
+	nodes, err := r.Read()
+	require.NoError(t, err)
+	if assert.Len(t, nodes, 1) {
+		assert.Equal(t, `apiVersion: v1
+kind: ConfigMap
+metadata:
+  name: test
+  labels:
+    app: test
+data:
+  literal: value
+  app.properties: |
+    a=1
+  x.yaml: |
+    x: 1
+  y.yaml: |
+    y: 2
+binaryData:
+  logo.png: iVBOR/8=
+  raw: cmF3
+immutable: true
+`, nodes[0].MustString())
+	}
+}
@@ -0,0 +1,172 @@
+/*
+Copyright 2023 GramLabs, Inc.
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package readers
+
+import (
+	"fmt"
+	"io"
+	"strings"
+	"time"
+
+	"sigs.k8s.io/kustomize/kyaml/yaml"
+)
+
+// Explanation records the tree of expansions performed by a filter.
+type Explanation struct {
+	// The number of iterations performed.
+	Iterations int `json:"iterations"`
+	// The total time spent expanding resources.
+	Duration time.Duration `json:"duration"`
+	// The final resources which were not produced by an expansion.
+	Resources []string `json:"resources,omitempty"`
+	// The top-level expansions.
+	Expansions []*Expansion `json:"expansions,omitempty"`
+}
+
+// Expansion records the expansion of a single Konjure resource.
+type Expansion struct {
+	Origin
+	// The iteration the expansion was performed in, starting at 1.
+	Iteration int `json:"iteration"`
+	// The time spent reading the Konjure resource.
+	Duration time.Duration `json:"duration"`
+	// The number of nodes produced by reading the Konjure resource.
+	Nodes int `json:"nodes"`
+	// The final resources produced directly by this expansion.
+	Resources []string `json:"resources,omitempty"`
+	// The expansions of the Konjure resources produced by this expansion.
+	Expansions []*Expansion `json:"expansions,omitempty"`
+}
+
+// WriteTree writes a human readable representation of the expansion tree.
+func (e *Explanation) WriteTree(w io.Writer) error {
+	if _, err := fmt.Fprintf(w, "%d iteration(s) in %s\n", e.Iterations, e.Duration); err != nil {
+		return err
+	}
+	return writeTree(w, "", e.Resources, e.Expansions)
+}
+
+// String returns a single line description of the expansion.
+func (e *Expansion) String() string {
+	desc := e.Kind
+	if e.Source != "" {
+		desc += " " + e.Source
+	}
+	if e.Ref != "" {
+		desc += "@" + e.Ref
+	}
+	return fmt.Sprintf("%s [iteration %d, %d node(s), %s]", desc, e.Iteration, e.Nodes, e.Duration)
+}
+
+// writeTree writes the resources and expansions as branches using the supplied prefix.
+func writeTree(w io.Writer, prefix string, resources []string, expansions []*Expansion) error {
+	n := len(resources) + len(expansions)
+	branch := func(i int) (string, string) {
+		if i == n-1 {
+			return prefix + "└── ", prefix + "    "
+		}
+		return prefix + "├── ", prefix + "│   "
+	}
+
+	for i, r := range resources {
+		first, _ := branch(i)
+		if _, err := fmt.Fprintf(w, "%s%s\n", first, r); err != nil {
+			return err
+		}
+	}
+
+	for i, e := range expansions {
+		first, rest := branch(len(resources) + i)
+		if _, err := fmt.Fprintf(w, "%s%s\n", first, e); err != nil {
+			return err
+		}
+		if err := writeTree(w, rest, e.Resources, e.Expansions); err != nil {
+			return err
+		}
+	}
+
+	return nil
+}
+
+// explainer is used to build an explanation as nodes are expanded. A nil
+// explainer does not record anything.
+type explainer struct {
+	*Explanation
+	start     time.Time
+	iteration int
+	// The expansion that produced each of the current nodes, nil for top-level nodes.
+	parents []*Expansion
+}
+
+// record adds the expansions performed during an iteration to the explanation.
+func (x *explainer) record(origins []*Origin, durations []time.Duration, expanded [][]*yaml.RNode) {
+	if x == nil {
+		return
+	}
+
+	x.iteration++
+	if x.parents == nil {
+		x.parents = make([]*Expansion, len(origins))
+	}
+
+	var parents []*Expansion
+	for i := range origins {
+		// Nodes which were not expanded retain their parent
+		parent := x.parents[i]
+		if origins[i] != nil {
+			e := &Expansion{Origin: *origins[i], Iteration: x.iteration, Duration: durations[i], Nodes: len(expanded[i])}
+			if parent != nil {
+				parent.Expansions = append(parent.Expansions, e)
+			} else {
+				x.Expansions = append(x.Expansions, e)
+			}
+			parent = e
+			x.Iterations = x.iteration
+		}
+
+		for range expanded[i] {
+			parents = append(parents, parent)
+		}
+	}
+	x.parents = parents
+}
+
+// finish records the final resources and the total elapsed time.
+func (x *explainer) finish(nodes []*yaml.RNode) {
+	if x == nil {
+		return
+	}
+
+	x.Duration = time.Since(x.start)
+	for i, n := range nodes {
+		name := resourceName(n)
+		if i < len(x.parents) && x.parents[i] != nil {
+			x.parents[i].Resources = append(x.parents[i].Resources, name)
+		} else {
+			x.Resources = append(x.Resources, name)
+		}
+	}
+}
+
+// resourceName returns the display name for a resource node.
+func resourceName(n *yaml.RNode) string {
+	name := strings.ToLower(n.GetKind()) + "/" + n.GetName()
+	if ns := n.GetNamespace(); ns != "" {
+		name = ns + "/" + name
+	}
+	return name
+}
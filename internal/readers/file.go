@@ -37,6 +37,8 @@ type FileReader struct {
 	Recurse bool
 	// Function used to determine an absolute path.
 	Abs func(path string) (string, error)
+	// Optional tracker used to record the files and directories that were read.
+	Tracker *Tracker
 }
 
 func (r *FileReader) Read() ([]*yaml.RNode, error) {
@@ -59,6 +61,9 @@ func (r *FileReader) Read() ([]*yaml.RNode, error) {
 				return filepath.SkipDir
 			}
 
+			// Track directories so files being added or removed are noticed
+			r.Tracker.Track(path)
+
 			// Check to see if a directory is a Kustomize root
 			if isKustomizeRoot(path) {
 				n, err := konjurev1beta2.GetRNode(&konjurev1beta2.Kustomize{Root: path})
@@ -90,6 +95,7 @@ func (r *FileReader) Read() ([]*yaml.RNode, error) {
 			if err != nil {
 				return err
 			}
+			r.Tracker.Track(path)
 
 			br := &kio.ByteReader{
 				Reader: bytes.NewReader(data),
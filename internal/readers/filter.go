@@ -17,9 +17,13 @@ limitations under the License.
 package readers
 
 import (
+	"context"
+	"errors"
 	"fmt"
+	"time"
 
 	konjurev1beta2 "github.com/thestormforge/konjure/pkg/api/core/v1beta2"
+	"golang.org/x/sync/errgroup"
 	"sigs.k8s.io/kustomize/kyaml/kio"
 	"sigs.k8s.io/kustomize/kyaml/yaml"
 )
@@ -34,41 +38,61 @@ type Filter struct {
 	Depth int
 	// Configuration options for the readers.
 	ReaderOptions []Option
+	// The maximum number of readers to invoke concurrently during an iteration,
+	// values less than 2 will read each node sequentially.
+	Concurrency int
+	// Optional explanation used to record the tree of expansions.
+	Explanation *Explanation
+	// The context used to cancel reading, defaults to the background context.
+	Context context.Context
 }
 
 // Filter expands all the Konjure resources using the configured executors.
 func (f *Filter) Filter(nodes []*yaml.RNode) ([]*yaml.RNode, error) {
 	var err error
 
+	// Only keep track of the expansions if we need to explain them
+	var x *explainer
+	if f.Explanation != nil {
+		x = &explainer{Explanation: f.Explanation, start: time.Now()}
+	}
+
 	// Recursively expand the nodes to the specified depth
-	nodes, err = f.expandToDepth(nodes, f.Depth)
+	nodes, err = f.expandToDepth(nodes, f.Depth, x)
 	if err != nil {
 		return nil, err
 	}
 
+	x.finish(nodes)
 	return nodes, nil
 }
 
 // expandToDepth applies the expansion executors up to the specified depth (i.e. a File executor that produces a
 // Kustomize resource would be at a depth of 2).
-func (f *Filter) expandToDepth(nodes []*yaml.RNode, depth int) ([]*yaml.RNode, error) {
+func (f *Filter) expandToDepth(nodes []*yaml.RNode, depth int, x *explainer) ([]*yaml.RNode, error) {
 	if depth <= 0 {
 		return nodes, nil
 	}
 
+	// Readers are cancelled if any other reader in the same iteration fails
+	g, ctx := errgroup.WithContext(f.context())
+	g.SetLimit(f.concurrency())
+
 	var opts []Option
 	opts = append(opts, f.ReaderOptions...)
+	opts = append(opts, WithContext(ctx))
+	opts = append(opts, f.withExpander(ctx, depth-1))
 
 	// Create a new cleaner for this iteration
 	cleanOpt, doClean := clean()
 	opts = append(opts, cleanOpt)
 	defer doClean()
 
-	// Process each of the nodes
-	result := make([]*yaml.RNode, 0, len(nodes))
-	done := true
+	// Create a reader for each of the nodes
+	readers := make([]kio.Reader, 0, len(nodes))
+	origins := make([]*Origin, 0, len(nodes))
 	for _, n := range nodes {
-		r, err := f.expand(n)
+		r, origin, err := f.expand(n)
 		if err != nil {
 			return nil, err
 		}
@@ -77,29 +101,85 @@ func (f *Filter) expandToDepth(nodes []*yaml.RNode, depth int) ([]*yaml.RNode, e
 			r = opt(r)
 		}
 
-		expanded, err := r.Read()
-		if err != nil {
+		readers = append(readers, r)
+		origins = append(origins, origin)
+	}
+
+	// Read the nodes, retaining the results by index to preserve ordering
+	expanded := make([][]*yaml.RNode, len(readers))
+	durations := make([]time.Duration, len(readers))
+	for i := range readers {
+		i := i
+		g.Go(func() (err error) {
+			// Do not start reading if another reader already failed
+			if err := ctx.Err(); err != nil {
+				return err
+			}
+			start := time.Now()
+			expanded[i], err = readers[i].Read()
+			durations[i] = time.Since(start)
+
+			// Identify which resource was cancelled or timed out
+			if origins[i] != nil && (errors.Is(err, context.DeadlineExceeded) || errors.Is(err, context.Canceled)) {
+				err = fmt.Errorf("%s %s: %w", origins[i].Kind, origins[i].Source, err)
+			}
+			return
+		})
+	}
+	if err := g.Wait(); err != nil {
+		return nil, err
+	}
+
+	// Record where each of the expanded nodes came from
+	for i, n := range nodes {
+		if origins[i] == nil {
+			continue
+		}
+		if err := annotateProvenance(n, origins[i], expanded[i]); err != nil {
 			return nil, err
 		}
+	}
 
-		done = done && len(expanded) == 1 && expanded[0] == n
+	x.record(origins, durations, expanded)
 
-		result = append(result, expanded...)
+	// Flatten the results, checking to see if anything changed
+	result := make([]*yaml.RNode, 0, len(nodes))
+	done := true
+	for i, n := range nodes {
+		done = done && len(expanded[i]) == 1 && expanded[i][0] == n
+		result = append(result, expanded[i]...)
 	}
 
 	// Perform another iteration if any of the nodes changed
 	if !done {
-		return f.expandToDepth(result, depth-1)
+		return f.expandToDepth(result, depth-1, x)
 	}
 	return result, nil
 }
 
-// expand returns a reader which can expand the supplied node. If the supplied node
-// cannot be expanded, the resulting reader will only produce that node.
-func (f *Filter) expand(node *yaml.RNode) (kio.Reader, error) {
+// context returns the context used for reading.
+func (f *Filter) context() context.Context {
+	if f.Context != nil {
+		return f.Context
+	}
+	return context.Background()
+}
+
+// concurrency returns the effective number of concurrent readers.
+func (f *Filter) concurrency() int {
+	if f.Concurrency > 1 {
+		return f.Concurrency
+	}
+	return 1
+}
+
+// expand returns a reader which can expand the supplied node along with the origin
+// to record on the expanded nodes. If the supplied node cannot be expanded, the
+// resulting reader will only produce that node and the origin will be nil.
+func (f *Filter) expand(node *yaml.RNode) (kio.Reader, *Origin, error) {
 	m, err := node.GetMeta()
 	if err != nil {
-		return nil, err
+		return nil, nil, err
 	}
 
 	switch {
@@ -108,21 +188,45 @@ func (f *Filter) expand(node *yaml.RNode) (kio.Reader, error) {
 		// Unmarshal the typed Konjure resource and create a reader from it
 		res, err := konjurev1beta2.NewForType(&m.TypeMeta)
 		if err != nil {
-			return nil, err
+			return nil, nil, err
 		}
 		if err := node.YNode().Decode(res); err != nil {
-			return nil, err
+			return nil, nil, err
+		}
+		if _, ok := res.(*konjurev1beta2.Policy); ok {
+			// Policies are not expanded, they are evaluated against the final result
+			return kio.ResourceNodeSlice{node}, nil, nil
 		}
 		r := New(res)
 		if r == nil {
-			return nil, fmt.Errorf("unable to read resources from type: %s", m.Kind)
+			return nil, nil, fmt.Errorf("unable to read resources from type: %s", m.Kind)
 		}
-		return r, nil
+		return r, newOrigin(res), nil
 
 	}
 
 	// The default behavior is to just return the node itself
-	return kio.ResourceNodeSlice{node}, nil
+	return kio.ResourceNodeSlice{node}, nil, nil
+}
+
+// withExpander configures readers which must fully expand other Konjure resources
+// using the remaining depth of this filter.
+func (f *Filter) withExpander(ctx context.Context, depth int) Option {
+	return func(r kio.Reader) kio.Reader {
+		expander := &Filter{
+			Depth:         depth,
+			ReaderOptions: f.ReaderOptions,
+			Concurrency:   f.Concurrency,
+			Context:       ctx,
+		}
+		switch rr := r.(type) {
+		case *PatchReader:
+			rr.Expander = expander
+		case *TransformReader:
+			rr.Expander = expander
+		}
+		return r
+	}
 }
 
 // clean is used to discover readers which implement `cleaner` and invoke their `Clean` function.
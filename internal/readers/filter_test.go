@@ -0,0 +1,210 @@
+/*
+Copyright 2023 GramLabs, Inc.
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package readers
+
+import (
+	"context"
+	"fmt"
+	"net/http"
+	"net/http/httptest"
+	"path/filepath"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+	konjurev1beta2 "github.com/thestormforge/konjure/pkg/api/core/v1beta2"
+	"sigs.k8s.io/kustomize/kyaml/yaml"
+)
+
+func TestFilter_Filter(t *testing.T) {
+	cases := []struct {
+		desc        string
+		concurrency int
+	}{
+		{
+			desc: "sequential",
+		},
+		{
+			desc:        "concurrent",
+			concurrency: 4,
+		},
+	}
+	for _, tc := range cases {
+		t.Run(tc.desc, func(t *testing.T) {
+			var nodes []*yaml.RNode
+			var expected []string
+			for i := 0; i < 20; i++ {
+				name := fmt.Sprintf("test-%02d", i)
+				if i%3 == 0 {
+					nodes = append(nodes, yaml.MustParse("apiVersion: v1\nkind: ConfigMap\nmetadata:\n  name: "+name+"\n"))
+				} else {
+					n, err := konjurev1beta2.GetRNode(&konjurev1beta2.Secret{SecretName: name})
+					require.NoError(t, err)
+					nodes = append(nodes, n)
+				}
+				expected = append(expected, name)
+			}
+
+			f := &Filter{Depth: 10, Concurrency: tc.concurrency}
+			actual, err := f.Filter(nodes)
+			if assert.NoError(t, err) {
+				var names []string
+				for _, n := range actual {
+					names = append(names, n.GetName())
+				}
+				assert.Equal(t, expected, names)
+			}
+		})
+	}
+}
+
+func TestFilter_Filter_provenance(t *testing.T) {
+	dir := t.TempDir()
+	writeFiles(t, dir, map[string]string{
+		"resources.yaml": `apiVersion: v1
+kind: ConfigMap
+metadata:
+  name: test-config
+---
+apiVersion: konjure.stormforge.io/v1beta2
+kind: Secret
+metadata:
+  name: test-secret
+secretName: test-secret
+`,
+	})
+
+	n, err := konjurev1beta2.GetRNode(&konjurev1beta2.File{Path: dir})
+	require.NoError(t, err)
+
+	f := &Filter{Depth: 10}
+	actual, err := f.Filter([]*yaml.RNode{n})
+	require.NoError(t, err)
+
+	provenance := make(map[string][]Origin, len(actual))
+	for _, n := range actual {
+		provenance[n.GetName()], err = GetProvenance(n)
+		require.NoError(t, err)
+	}
+
+	file := Origin{Kind: "File", Source: dir, File: filepath.Join(dir, "resources.yaml")}
+	assert.Equal(t, map[string][]Origin{
+		"test-config": {withIndex(file, "0")},
+		"test-secret": {withIndex(file, "1"), {Kind: "Secret", Source: "test-secret", Index: "0"}},
+	}, provenance)
+}
+
+func TestFilter_Filter_nestedProvenance(t *testing.T) {
+	dir := t.TempDir()
+	writeFiles(t, dir, map[string]string{
+		"patch.yaml": `apiVersion: konjure.stormforge.io/v1beta2
+kind: Patch
+metadata:
+  name: test-patch
+sources:
+- apiVersion: konjure.stormforge.io/v1beta2
+  kind: Secret
+  secretName: test-secret
+patches:
+- values:
+  - metadata.labels.patched=true
+`,
+	})
+
+	n, err := konjurev1beta2.GetRNode(&konjurev1beta2.File{Path: dir})
+	require.NoError(t, err)
+
+	f := &Filter{Depth: 10}
+	actual, err := f.Filter([]*yaml.RNode{n})
+	require.NoError(t, err)
+	require.Len(t, actual, 1)
+	assert.Equal(t, "true", actual[0].GetLabels()["patched"])
+
+	provenance, err := GetProvenance(actual[0])
+	require.NoError(t, err)
+	assert.Equal(t, []Origin{
+		{Kind: "File", Source: dir, File: filepath.Join(dir, "patch.yaml"), Index: "0"},
+		{Kind: "Patch", Index: "0"},
+		{Kind: "Secret", Source: "test-secret", Index: "0"},
+	}, provenance)
+}
+
+func withIndex(o Origin, index string) Origin {
+	o.Index = index
+	return o
+}
+
+func TestFilter_Filter_explanation(t *testing.T) {
+	dir := t.TempDir()
+	writeFiles(t, dir, map[string]string{
+		"resources.yaml": `apiVersion: v1
+kind: ConfigMap
+metadata:
+  name: test-config
+---
+apiVersion: konjure.stormforge.io/v1beta2
+kind: Secret
+metadata:
+  name: test-secret
+secretName: test-secret
+`,
+	})
+
+	n, err := konjurev1beta2.GetRNode(&konjurev1beta2.File{Path: dir})
+	require.NoError(t, err)
+	other := yaml.MustParse("apiVersion: v1\nkind: ConfigMap\nmetadata:\n  name: other\n  namespace: default\n")
+
+	f := &Filter{Depth: 10, Explanation: &Explanation{}}
+	_, err = f.Filter([]*yaml.RNode{other, n})
+	require.NoError(t, err)
+
+	x := f.Explanation
+	assert.Equal(t, 2, x.Iterations)
+	assert.Equal(t, []string{"default/configmap/other"}, x.Resources)
+	if assert.Len(t, x.Expansions, 1) {
+		file := x.Expansions[0]
+		assert.Equal(t, "File", file.Kind)
+		assert.Equal(t, 1, file.Iteration)
+		assert.Equal(t, 2, file.Nodes)
+		assert.Equal(t, []string{"configmap/test-config"}, file.Resources)
+		if assert.Len(t, file.Expansions, 1) {
+			secret := file.Expansions[0]
+			assert.Equal(t, "Secret", secret.Kind)
+			assert.Equal(t, 2, secret.Iteration)
+			assert.Equal(t, []string{"secret/test-secret"}, secret.Resources)
+			assert.Empty(t, secret.Expansions)
+		}
+	}
+}
+
+func TestFilter_Filter_timeout(t *testing.T) {
+	// The server never responds before the client gives up
+	srv := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		<-r.Context().Done()
+	}))
+	defer srv.Close()
+
+	n, err := konjurev1beta2.GetRNode(&konjurev1beta2.HTTP{URL: srv.URL, Timeout: "50ms"})
+	require.NoError(t, err)
+
+	f := &Filter{Depth: 10}
+	_, err = f.Filter([]*yaml.RNode{n})
+	if assert.Error(t, err) {
+		assert.ErrorIs(t, err, context.DeadlineExceeded)
+		assert.Contains(t, err.Error(), "HTTP "+srv.URL)
+	}
+}
@@ -17,62 +17,151 @@ limitations under the License.
 package readers
 
 import (
-	"io/ioutil"
+	"bufio"
+	"bytes"
+	"context"
+	"errors"
+	"fmt"
+	"io"
 	"os"
-	"os/exec"
+	"path"
 	"path/filepath"
+	"regexp"
+	"strings"
+	"time"
 
+	"github.com/go-git/go-billy/v5/osfs"
+	"github.com/go-git/go-git/v5"
+	"github.com/go-git/go-git/v5/config"
+	"github.com/go-git/go-git/v5/plumbing"
+	"github.com/go-git/go-git/v5/plumbing/cache"
+	"github.com/go-git/go-git/v5/plumbing/filemode"
+	"github.com/go-git/go-git/v5/plumbing/object"
+	"github.com/go-git/go-git/v5/plumbing/transport"
+	githttp "github.com/go-git/go-git/v5/plumbing/transport/http"
+	gitssh "github.com/go-git/go-git/v5/plumbing/transport/ssh"
+	"github.com/go-git/go-git/v5/storage"
+	"github.com/go-git/go-git/v5/storage/filesystem"
+	"github.com/go-git/go-git/v5/storage/memory"
+	"github.com/gofrs/flock"
 	konjurev1beta2 "github.com/thestormforge/konjure/pkg/api/core/v1beta2"
 	"sigs.k8s.io/kustomize/kyaml/yaml"
 )
 
+// mirrorLockFile is the name of the file used to serialize access to a mirror.
+const mirrorLockFile = ".konjure-mirror.lock"
+
+// commitPattern matches a full Git commit SHA.
+var commitPattern = regexp.MustCompile(`^[0-9a-f]{40}$`)
+
 type GitReader struct {
 	konjurev1beta2.Git
-	path string
+	// The token used to authenticate HTTP(S) requests, if empty the `.netrc` file is consulted.
+	Token string
+	// Overrides the authentication method used to access the repository.
+	Auth transport.AuthMethod
+	// The cache used to share mirrors and checkouts across runs.
+	Cache *Cache
+	// The lock used to pin the checkout to a specific commit.
+	Lock *Lock
+
+	path   string
+	cached bool
+	ctx    context.Context
 }
 
 func (r *GitReader) Read() ([]*yaml.RNode, error) {
-	var err error
-	r.path, err = ioutil.TempDir("", "konjure-git")
+	ctx, cancel, err := readContext(r.ctx, r.Timeout)
 	if err != nil {
 		return nil, err
 	}
+	defer cancel()
 
 	refspec := r.Refspec
 	if refspec == "" {
 		refspec = "HEAD"
 	}
 
-	// Fetch the into the temporary directory
-	if err := r.run("init"); err != nil {
+	// Fetch the locked commit instead of the requested refspec
+	locked, err := r.Lock.git(r.Repository, r.Refspec)
+	if err != nil {
 		return nil, err
 	}
-	if err := r.run("remote", "add", "origin", r.Repository); err != nil {
+	if locked != "" {
+		refspec = locked
+	}
+
+	auth, err := r.auth()
+	if err != nil {
 		return nil, err
 	}
-	if err := r.run("fetch", "--depth=1", "origin", refspec); err != nil {
+
+	mirror, unlock, err := r.mirror(ctx)
+	if err != nil {
 		return nil, err
 	}
-	if err := r.run("checkout", "FETCH_HEAD"); err != nil {
+	defer unlock()
+
+	commit, err := r.fetch(ctx, mirror, auth, refspec)
+	if err != nil {
+		if locked != "" {
+			return nil, fmt.Errorf("unable to fetch locked commit %s from %s: %w", locked, r.Repository, err)
+		}
 		return nil, err
 	}
-	if err := r.run("submodule", "update", "--init", "--recursive"); err != nil {
+
+	// Make sure we got what we asked for
+	if err := r.Lock.verifyGit(r.Repository, r.Refspec, commit.String()); err != nil {
 		return nil, err
 	}
 
-	// This creates a single File resource for the subdirectory of the Git repository
-	// TODO Annotate the File resource with the Git information? (that should be whenever a Konjure resource creates another Konjure resource)
-	n, err := konjurev1beta2.GetRNode(&konjurev1beta2.File{
-		Path: filepath.Join(r.path, r.Context),
-	})
+	// Look for an existing checkout of the resolved commit
+	key, err := r.cacheKey(commit.String())
 	if err != nil {
 		return nil, err
 	}
-	return []*yaml.RNode{n}, nil
+	if key != "" {
+		r.path = r.Cache.Path("git", key)
+		if _, err := os.Stat(r.path); err == nil {
+			// Record the access so recently used checkouts survive a prune
+			now := time.Now()
+			_ = os.Chtimes(r.path, now, now)
+
+			r.cached = true
+			return r.file()
+		}
+	}
+
+	// When caching, check out next to the final location so it can be renamed into place
+	dir := ""
+	if key != "" {
+		dir = filepath.Dir(r.Cache.Path("git", key))
+		if err := os.MkdirAll(dir, 0700); err != nil {
+			return nil, err
+		}
+	}
+
+	r.path, err = os.MkdirTemp(dir, "konjure-git")
+	if err != nil {
+		return nil, err
+	}
+
+	if err := r.checkout(ctx, mirror, commit); err != nil {
+		return nil, err
+	}
+
+	// Move the checkout into the cache
+	if key != "" {
+		if err := r.store(key); err != nil {
+			return nil, err
+		}
+	}
+
+	return r.file()
 }
 
 func (r *GitReader) Clean() error {
-	if r.path == "" {
+	if r.path == "" || r.cached {
 		return nil
 	}
 	if err := os.RemoveAll(r.path); err != nil {
@@ -82,8 +171,461 @@ func (r *GitReader) Clean() error {
 	return nil
 }
 
-func (r *GitReader) run(arg ...string) error {
-	cmd := exec.Command("git", arg...)
-	cmd.Dir = r.path
-	return cmd.Run()
+// file creates a single File resource for the subdirectory of the Git repository.
+func (r *GitReader) file() ([]*yaml.RNode, error) {
+	n, err := konjurev1beta2.GetRNode(&konjurev1beta2.File{
+		Path: filepath.Join(r.path, r.Context),
+	})
+	if err != nil {
+		return nil, err
+	}
+	return []*yaml.RNode{n}, nil
+}
+
+// cacheKey returns the key used to identify the checkout in the cache. An empty
+// key is returned if there is no cache.
+func (r *GitReader) cacheKey(commit string) (string, error) {
+	if r.Cache == nil || commit == "" {
+		return "", nil
+	}
+
+	// Only the context is checked out, it must be part of the key
+	return r.Cache.Key(r.Repository, commit, path.Clean("/"+r.Context))
+}
+
+// mirror returns the storage for the bare mirror of the repository. When there
+// is a cache, the mirror is shared across readers and runs (including other
+// processes); the returned function must be called to release the mirror once
+// the checkout is complete.
+func (r *GitReader) mirror(ctx context.Context) (storage.Storer, func(), error) {
+	if r.Cache == nil {
+		return memory.NewStorage(), func() {}, nil
+	}
+
+	key, err := r.Cache.Key(r.Repository)
+	if err != nil {
+		return nil, nil, err
+	}
+
+	dir := r.Cache.Path("git-mirror", key)
+	if err := os.MkdirAll(dir, 0700); err != nil {
+		return nil, nil, err
+	}
+
+	fl := flock.New(filepath.Join(dir, mirrorLockFile))
+	if _, err := fl.TryLockContext(ctx, 100*time.Millisecond); err != nil {
+		return nil, nil, fmt.Errorf("unable to lock Git mirror for %s: %w", r.Repository, err)
+	}
+	unlock := func() {
+		// Record the access so recently used mirrors survive a prune
+		now := time.Now()
+		_ = os.Chtimes(dir, now, now)
+		_ = fl.Unlock()
+	}
+
+	s := filesystem.NewStorage(osfs.New(dir), cache.NewObjectLRUDefault())
+	if _, err := git.Init(s, nil); err != nil && !errors.Is(err, git.ErrRepositoryAlreadyExists) {
+		unlock()
+		return nil, nil, err
+	}
+
+	return s, unlock, nil
+}
+
+// fetch retrieves the commit for the supplied refspec into the mirror.
+func (r *GitReader) fetch(ctx context.Context, s storage.Storer, auth transport.AuthMethod, refspec string) (plumbing.Hash, error) {
+	remote := git.NewRemote(s, &config.RemoteConfig{
+		Name: git.DefaultRemoteName,
+		URLs: []string{r.Repository},
+	})
+
+	opts := &git.FetchOptions{
+		Auth:  auth,
+		Tags:  git.NoTags,
+		Force: true,
+	}
+
+	var commit plumbing.Hash
+	if commitPattern.MatchString(refspec) {
+		// Commits cannot be requested directly, fetch all the branches and tags
+		commit = plumbing.NewHash(refspec)
+		opts.RefSpecs = []config.RefSpec{"+refs/heads/*:refs/heads/*", "+refs/tags/*:refs/tags/*"}
+	} else {
+		var name plumbing.ReferenceName
+		var err error
+		name, commit, err = r.resolve(ctx, remote, auth, refspec)
+		if err != nil {
+			return plumbing.ZeroHash, err
+		}
+		opts.RefSpecs = []config.RefSpec{config.RefSpec(fmt.Sprintf("+%s:%s", name, name))}
+		if r.Cache == nil && !isLocal(r.Repository) {
+			// There is no mirror to reuse, only fetch what is needed
+			opts.Depth = 1
+		}
+	}
+
+	// Only go to the remote if the mirror does not already have the commit
+	if _, err := object.GetCommit(s, commit); err == nil {
+		return commit, nil
+	}
+
+	var progress bytes.Buffer
+	opts.Progress = &progress
+	if err := remote.FetchContext(ctx, opts); err != nil && !errors.Is(err, git.NoErrAlreadyUpToDate) {
+		return plumbing.ZeroHash, gitError("fetch", r.Repository, refspec, err, &progress)
+	}
+
+	if _, err := object.GetCommit(s, commit); err != nil {
+		return plumbing.ZeroHash, fmt.Errorf("git fetch %s %s: commit %s is not available: %w", r.Repository, refspec, commit, err)
+	}
+
+	return commit, nil
+}
+
+// resolve lists the remote references to find the reference name and commit for
+// the supplied refspec. Branches are preferred over tags of the same name.
+func (r *GitReader) resolve(ctx context.Context, remote *git.Remote, auth transport.AuthMethod, refspec string) (plumbing.ReferenceName, plumbing.Hash, error) {
+	refs, err := remote.ListContext(ctx, &git.ListOptions{Auth: auth, PeelingOption: git.AppendPeeled})
+	if err != nil {
+		return "", plumbing.ZeroHash, gitError("ls-remote", r.Repository, refspec, err, nil)
+	}
+
+	byName := make(map[plumbing.ReferenceName]*plumbing.Reference, len(refs))
+	for _, ref := range refs {
+		byName[ref.Name()] = ref
+	}
+
+	for _, name := range []plumbing.ReferenceName{
+		plumbing.ReferenceName(refspec),
+		plumbing.NewBranchReferenceName(refspec),
+		plumbing.NewTagReferenceName(refspec),
+	} {
+		ref, ok := byName[name]
+		if !ok {
+			continue
+		}
+
+		// Follow symbolic references (e.g. HEAD) to the actual branch
+		if ref.Type() == plumbing.SymbolicReference {
+			name = ref.Target()
+			if ref, ok = byName[name]; !ok {
+				continue
+			}
+		}
+
+		// Annotated tags must be peeled to get the commit
+		if peeled, ok := byName[name+"^{}"]; ok {
+			return name, peeled.Hash(), nil
+		}
+		return name, ref.Hash(), nil
+	}
+
+	return "", plumbing.ZeroHash, fmt.Errorf("git ls-remote %s %s: reference not found", r.Repository, refspec)
+}
+
+// checkout writes the files for the configured context of the commit into the
+// current path; anything outside the context is never written.
+func (r *GitReader) checkout(ctx context.Context, s storage.Storer, commit plumbing.Hash) error {
+	c, err := object.GetCommit(s, commit)
+	if err != nil {
+		return err
+	}
+
+	root, err := c.Tree()
+	if err != nil {
+		return err
+	}
+
+	modules, err := submodules(root)
+	if err != nil {
+		return err
+	}
+
+	dir := strings.Trim(path.Clean("/"+filepath.ToSlash(r.Context)), "/")
+	if dir == "" {
+		return r.writeTree(ctx, s, root, "", modules)
+	}
+
+	entry, err := root.FindEntry(dir)
+	if err != nil {
+		return fmt.Errorf("git checkout %s %s: %w: %s", r.Repository, commit, err, dir)
+	}
+	return r.writeEntry(ctx, s, entry, dir, modules)
+}
+
+// writeTree recursively writes the contents of a tree.
+func (r *GitReader) writeTree(ctx context.Context, s storage.Storer, t *object.Tree, dir string, modules map[string]*config.Submodule) error {
+	for i := range t.Entries {
+		if err := r.writeEntry(ctx, s, &t.Entries[i], path.Join(dir, t.Entries[i].Name), modules); err != nil {
+			return err
+		}
+	}
+	return nil
+}
+
+// writeEntry writes a single tree entry to the supplied repository relative path.
+func (r *GitReader) writeEntry(ctx context.Context, s storage.Storer, entry *object.TreeEntry, name string, modules map[string]*config.Submodule) error {
+	dst := filepath.Join(r.path, filepath.FromSlash(name))
+	if err := os.MkdirAll(filepath.Dir(dst), 0755); err != nil {
+		return err
+	}
+
+	switch entry.Mode {
+	case filemode.Dir:
+		t, err := object.GetTree(s, entry.Hash)
+		if err != nil {
+			return err
+		}
+		if err := os.MkdirAll(dst, 0755); err != nil {
+			return err
+		}
+		return r.writeTree(ctx, s, t, name, modules)
+
+	case filemode.Submodule:
+		return r.writeSubmodule(ctx, name, entry.Hash, modules)
+
+	case filemode.Symlink:
+		data, err := readBlob(s, entry.Hash)
+		if err != nil {
+			return err
+		}
+		return os.Symlink(string(data), dst)
+
+	default:
+		data, err := readBlob(s, entry.Hash)
+		if err != nil {
+			return err
+		}
+		perm := os.FileMode(0644)
+		if entry.Mode == filemode.Executable {
+			perm = 0755
+		}
+		return os.WriteFile(dst, data, perm)
+	}
+}
+
+// writeSubmodule fetches and writes the contents of a submodule.
+func (r *GitReader) writeSubmodule(ctx context.Context, name string, commit plumbing.Hash, modules map[string]*config.Submodule) error {
+	m, ok := modules[name]
+	if !ok {
+		return fmt.Errorf("git submodule %s in %s is not configured", name, r.Repository)
+	}
+
+	repo, err := submoduleURL(r.Repository, m.URL)
+	if err != nil {
+		return err
+	}
+
+	sub := &GitReader{
+		Git:   konjurev1beta2.Git{Repository: repo},
+		Token: r.Token,
+		Cache: r.Cache,
+		path:  filepath.Join(r.path, filepath.FromSlash(name)),
+	}
+
+	auth, err := sub.auth()
+	if err != nil {
+		return err
+	}
+
+	s, unlock, err := sub.mirror(ctx)
+	if err != nil {
+		return err
+	}
+	defer unlock()
+
+	if _, err := sub.fetch(ctx, s, auth, commit.String()); err != nil {
+		return err
+	}
+
+	if err := os.MkdirAll(sub.path, 0755); err != nil {
+		return err
+	}
+	return sub.checkout(ctx, s, commit)
+}
+
+// auth returns the authentication method for the repository.
+func (r *GitReader) auth() (transport.AuthMethod, error) {
+	if r.Auth != nil {
+		return r.Auth, nil
+	}
+
+	ep, err := transport.NewEndpoint(r.Repository)
+	if err != nil {
+		return nil, err
+	}
+
+	switch ep.Protocol {
+	case "ssh":
+		user := ep.User
+		if user == "" {
+			user = "git"
+		}
+
+		// If there is no agent, let Git try the default authentication
+		if auth, err := gitssh.NewSSHAgentAuth(user); err == nil {
+			return auth, nil
+		}
+
+	case "http", "https":
+		// Credentials on the URL are used automatically
+		if ep.User != "" {
+			return nil, nil
+		}
+
+		if r.Token != "" {
+			return &githttp.BasicAuth{Username: "x-access-token", Password: r.Token}, nil
+		}
+
+		if login, password := netrc(ep.Host); login != "" || password != "" {
+			return &githttp.BasicAuth{Username: login, Password: password}, nil
+		}
+	}
+
+	return nil, nil
+}
+
+// store moves the current checkout into the cache.
+func (r *GitReader) store(key string) error {
+	path := r.Cache.Path("git", key)
+
+	// If the rename fails, someone else may have stored the same commit concurrently
+	if err := os.Rename(r.path, path); err != nil {
+		if _, statErr := os.Stat(path); statErr != nil {
+			return err
+		}
+		_ = os.RemoveAll(r.path)
+	}
+
+	r.path = path
+	r.cached = true
+	return nil
+}
+
+// submodules returns the submodule configuration from the root tree, keyed by path.
+func submodules(root *object.Tree) (map[string]*config.Submodule, error) {
+	f, err := root.File(".gitmodules")
+	if errors.Is(err, object.ErrFileNotFound) {
+		return nil, nil
+	} else if err != nil {
+		return nil, err
+	}
+
+	contents, err := f.Contents()
+	if err != nil {
+		return nil, err
+	}
+
+	m := config.NewModules()
+	if err := m.Unmarshal([]byte(contents)); err != nil {
+		return nil, err
+	}
+
+	result := make(map[string]*config.Submodule, len(m.Submodules))
+	for _, s := range m.Submodules {
+		result[path.Clean(s.Path)] = s
+	}
+	return result, nil
+}
+
+// submoduleURL resolves a submodule URL relative to the parent repository.
+func submoduleURL(parent, sub string) (string, error) {
+	if !strings.HasPrefix(sub, "./") && !strings.HasPrefix(sub, "../") {
+		return sub, nil
+	}
+
+	ep, err := transport.NewEndpoint(parent)
+	if err != nil {
+		return "", err
+	}
+
+	// Relative URLs are resolved against the parent repository "directory"
+	ep.Path = path.Join(ep.Path, sub)
+	if ep.Protocol == "file" {
+		return ep.Path, nil
+	}
+	return ep.String(), nil
+}
+
+// readBlob returns the contents of a blob.
+func readBlob(s storage.Storer, hash plumbing.Hash) ([]byte, error) {
+	blob, err := object.GetBlob(s, hash)
+	if err != nil {
+		return nil, err
+	}
+
+	rc, err := blob.Reader()
+	if err != nil {
+		return nil, err
+	}
+	defer rc.Close()
+
+	return io.ReadAll(rc)
+}
+
+// netrc returns the login and password for the supplied host from the user's
+// `.netrc` file (or the file named by the `NETRC` environment variable).
+func netrc(host string) (login, password string) {
+	name := os.Getenv("NETRC")
+	if name == "" {
+		home, err := os.UserHomeDir()
+		if err != nil {
+			return "", ""
+		}
+		name = filepath.Join(home, ".netrc")
+	}
+
+	f, err := os.Open(name)
+	if err != nil {
+		return "", ""
+	}
+	defer f.Close()
+
+	scanner := bufio.NewScanner(f)
+	scanner.Split(bufio.ScanWords)
+	var match, found bool
+	for scanner.Scan() {
+		switch scanner.Text() {
+		case "machine":
+			if found {
+				return login, password
+			}
+			match = scanner.Scan() && scanner.Text() == host
+			found = match
+		case "default":
+			if found {
+				return login, password
+			}
+			match = true
+			found = true
+		case "login":
+			if scanner.Scan() && match {
+				login = scanner.Text()
+			}
+		case "password":
+			if scanner.Scan() && match {
+				password = scanner.Text()
+			}
+		case "macdef":
+			// Macros are terminated by a blank line, which the word scanner cannot see
+			return login, password
+		}
+	}
+	return login, password
+}
+
+// gitError produces an error that includes the messages sent by the remote.
+func gitError(op, repo, refspec string, err error, progress *bytes.Buffer) error {
+	if progress != nil {
+		if msg := strings.TrimSpace(progress.String()); msg != "" {
+			return fmt.Errorf("git %s %s %s: %w: %s", op, repo, refspec, err, msg)
+		}
+	}
+	return fmt.Errorf("git %s %s %s: %w", op, repo, refspec, err)
+}
+
+// isLocal checks if the repository is on the local file system (like Git, depth is ignored for local repositories).
+func isLocal(repo string) bool {
+	ep, err := transport.NewEndpoint(repo)
+	return err == nil && ep.Protocol == "file"
 }
@@ -0,0 +1,178 @@
+/*
+Copyright 2023 GramLabs, Inc.
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package readers
+
+import (
+	"os"
+	"path/filepath"
+	"testing"
+	"time"
+
+	"github.com/go-git/go-billy/v5/osfs"
+	"github.com/go-git/go-git/v5"
+	"github.com/go-git/go-git/v5/plumbing/object"
+	"github.com/go-git/go-git/v5/plumbing/transport/client"
+	"github.com/go-git/go-git/v5/plumbing/transport/file"
+	"github.com/go-git/go-git/v5/plumbing/transport/server"
+	"github.com/gofrs/flock"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+	konjurev1beta2 "github.com/thestormforge/konjure/pkg/api/core/v1beta2"
+)
+
+func TestGitReader_Read(t *testing.T) {
+	// Serve local repositories in-process instead of using `git-upload-pack`
+	client.InstallProtocol("file", server.NewClient(server.NewFilesystemLoader(osfs.New("/"))))
+	t.Cleanup(func() { client.InstallProtocol("file", file.DefaultClient) })
+
+	repo := t.TempDir()
+	writeFiles(t, repo, map[string]string{
+		"app/configmap.yaml": "apiVersion: v1\nkind: ConfigMap\nmetadata:\n  name: app\n",
+		"other/secret.yaml":  "apiVersion: v1\nkind: Secret\nmetadata:\n  name: other\n",
+	})
+	commit := commitAll(t, repo, "v1.0.0")
+	url := filepath.Join(repo, ".git")
+
+	cases := []struct {
+		desc    string
+		refspec string
+		cache   bool
+		err     string
+	}{
+		{
+			desc: "default branch",
+		},
+		{
+			desc:    "tag",
+			refspec: "v1.0.0",
+		},
+		{
+			desc:    "commit",
+			refspec: commit,
+			cache:   true,
+		},
+		{
+			desc:  "cached mirror",
+			cache: true,
+		},
+		{
+			desc:    "missing reference",
+			refspec: "missing",
+			err:     "reference not found",
+		},
+	}
+
+	cache := &Cache{Dir: t.TempDir()}
+	for _, c := range cases {
+		t.Run(c.desc, func(t *testing.T) {
+			r := &GitReader{Git: konjurev1beta2.Git{Repository: url, Refspec: c.refspec, Context: "app"}}
+			if c.cache {
+				r.Cache = cache
+			}
+			defer func() { assert.NoError(t, r.Clean()) }()
+
+			// Age any existing checkouts so reuse is visible to a prune
+			checkouts, _ := os.ReadDir(filepath.Join(cache.Dir, "git"))
+			for _, checkout := range checkouts {
+				aged := time.Now().Add(-24 * time.Hour)
+				require.NoError(t, os.Chtimes(filepath.Join(cache.Dir, "git", checkout.Name()), aged, aged))
+			}
+
+			nodes, err := r.Read()
+			if c.err != "" {
+				if assert.Error(t, err) {
+					assert.Contains(t, err.Error(), c.err)
+				}
+				return
+			}
+			require.NoError(t, err)
+			require.Len(t, nodes, 1)
+
+			// Only the context should be checked out
+			assert.FileExists(t, filepath.Join(r.path, "app", "configmap.yaml"))
+			assert.NoDirExists(t, filepath.Join(r.path, "other"))
+			assert.Equal(t, filepath.Join(r.path, "app"), nodes[0].Field("path").Value.YNode().Value)
+
+			// The mirror should be shared through the cache
+			if c.cache {
+				mirrors, err := os.ReadDir(filepath.Join(cache.Dir, "git-mirror"))
+				if assert.NoError(t, err) {
+					assert.Len(t, mirrors, 1)
+				}
+
+				info, err := os.Stat(r.path)
+				if assert.NoError(t, err) {
+					assert.WithinDuration(t, time.Now(), info.ModTime(), time.Hour)
+				}
+			}
+		})
+	}
+}
+
+func TestGitReader_Read_lockedMirror(t *testing.T) {
+	client.InstallProtocol("file", server.NewClient(server.NewFilesystemLoader(osfs.New("/"))))
+	t.Cleanup(func() { client.InstallProtocol("file", file.DefaultClient) })
+
+	repo := t.TempDir()
+	writeFiles(t, repo, map[string]string{
+		"configmap.yaml": "apiVersion: v1\nkind: ConfigMap\nmetadata:\n  name: app\n",
+	})
+	commitAll(t, repo, "")
+	url := filepath.Join(repo, ".git")
+
+	// Hold the mirror lock the same way another process would
+	cache := &Cache{Dir: t.TempDir()}
+	key, err := cache.Key(url)
+	require.NoError(t, err)
+	dir := cache.Path("git-mirror", key)
+	require.NoError(t, os.MkdirAll(dir, 0700))
+	fl := flock.New(filepath.Join(dir, mirrorLockFile))
+	require.NoError(t, fl.Lock())
+
+	r := &GitReader{Git: konjurev1beta2.Git{Repository: url, Timeout: "100ms"}, Cache: cache}
+	_, err = r.Read()
+	if assert.Error(t, err) {
+		assert.Contains(t, err.Error(), "unable to lock Git mirror")
+	}
+
+	require.NoError(t, fl.Unlock())
+	r = &GitReader{Git: konjurev1beta2.Git{Repository: url}, Cache: cache}
+	defer func() { assert.NoError(t, r.Clean()) }()
+	nodes, err := r.Read()
+	require.NoError(t, err)
+	assert.Len(t, nodes, 1)
+}
+
+func commitAll(t *testing.T, dir, tag string) string {
+	repo, err := git.PlainInit(dir, false)
+	require.NoError(t, err)
+	cfg, err := repo.Config()
+	require.NoError(t, err)
+	require.NoError(t, repo.SetConfig(cfg)) // The server loader expects a config file
+	w, err := repo.Worktree()
+	require.NoError(t, err)
+	require.NoError(t, w.AddGlob("."))
+	hash, err := w.Commit("test", &git.CommitOptions{
+		Author: &object.Signature{Name: "test", Email: "test@example.com", When: time.Now()},
+	})
+	require.NoError(t, err)
+	if tag != "" {
+		_, err := repo.CreateTag(tag, hash, nil)
+		require.NoError(t, err)
+	}
+	return hash.String()
+}
@@ -17,11 +17,24 @@ limitations under the License.
 package readers
 
 import (
+	"bytes"
+	"context"
 	"fmt"
+	"net/http"
+	"os"
 	"path/filepath"
+	"strings"
 
+	"github.com/Masterminds/semver/v3"
 	konjurev1beta2 "github.com/thestormforge/konjure/pkg/api/core/v1beta2"
 	"github.com/thestormforge/konjure/pkg/filters"
+	"helm.sh/helm/v3/pkg/action"
+	"helm.sh/helm/v3/pkg/chart"
+	"helm.sh/helm/v3/pkg/chart/loader"
+	"helm.sh/helm/v3/pkg/cli"
+	"helm.sh/helm/v3/pkg/cli/values"
+	"helm.sh/helm/v3/pkg/getter"
+	"helm.sh/helm/v3/pkg/registry"
 	"sigs.k8s.io/kustomize/kyaml/kio"
 	"sigs.k8s.io/kustomize/kyaml/yaml"
 )
@@ -32,10 +45,278 @@ type HelmReader struct {
 
 	// The path to the Helm repository cache. Corresponds to the `helm --repository-cache` option.
 	RepositoryCache string
+	// The path to the registry credentials. Corresponds to the `helm --registry-config` option.
+	RegistryConfig string
+	// The HTTP client used to access chart repositories and OCI registries.
+	Client *http.Client
+	// The cache used to share rendered charts across runs.
+	Cache *Cache
+	// The lock used to pin the chart to a specific version.
+	Lock *Lock
+	// Optional tracker used to record the local value files.
+	Tracker *Tracker
+
+	resolvedVersion string
+	resolvedDigest  string
+	pulled          *registry.PullResult
+	ctx             context.Context
 }
 
 func (helm *HelmReader) Read() ([]*yaml.RNode, error) {
-	cmd := helm.command()
+	ctx, cancel, err := readContext(helm.ctx, helm.Timeout)
+	if err != nil {
+		return nil, err
+	}
+	defer cancel()
+
+	// Locking and caching happen here so an abandoned render cannot modify them
+	if err := helm.lock(ctx); err != nil {
+		return nil, err
+	}
+
+	helm.Tracker.Track(helm.localFiles()...)
+
+	key := helm.cacheKey(ctx)
+	if nodes, err := helm.Cache.Get("helm", key); err != nil || nodes != nil {
+		return nodes, err
+	}
+
+	nodes, err := helm.expand(ctx)
+	if err != nil {
+		return nil, err
+	}
+	if err := ctx.Err(); err != nil {
+		return nil, err
+	}
+
+	if err := helm.Cache.Put("helm", key, nodes); err != nil {
+		return nil, err
+	}
+
+	return nodes, nil
+}
+
+// expand produces the resources for the chart.
+func (helm *HelmReader) expand(ctx context.Context) ([]*yaml.RNode, error) {
+	p := &filters.Pipeline{}
+
+	// Only use the helm binary if it was explicitly requested
+	if helm.Bin != "" || helm.Executor != nil {
+		p.Inputs = append(p.Inputs, helm.templateCommand(ctx))
+	} else {
+		nodes, err := readAsync(ctx, func() ([]*yaml.RNode, error) { return helm.render(ctx) })
+		if err != nil {
+			return nil, err
+		}
+		p.Inputs = append(p.Inputs, kio.ResourceNodeSlice(nodes))
+	}
+
+	if !helm.IncludeTests {
+		p.Filters = append(p.Filters, &filters.ResourceMetaFilter{
+			AnnotationSelector: "helm.sh/hook notin (test, test-success, test-failure)",
+		})
+	}
+
+	return p.Read()
+}
+
+// render uses the Helm SDK to render the chart in-process.
+func (helm *HelmReader) render(ctx context.Context) ([]*yaml.RNode, error) {
+	settings := helm.settings()
+
+	// A client only install is equivalent to `helm template`
+	install := action.NewInstall(&action.Configuration{})
+	install.DryRun = true
+	install.ClientOnly = true
+	install.Replace = true
+	install.ReleaseName = helm.ReleaseName
+	install.GenerateName = helm.ReleaseName == ""
+	install.Namespace = helm.ReleaseNamespace
+	install.RepoURL = helm.Repository
+	install.Version = helm.Version
+	if install.Namespace == "" {
+		install.Namespace = settings.Namespace()
+	}
+
+	name, chartName, err := install.NameAndChart([]string{helm.Chart})
+	if err != nil {
+		return nil, err
+	}
+	install.ReleaseName = name
+
+	ch, err := helm.loadChart(ctx, install, chartName, settings)
+	if err != nil {
+		return nil, err
+	}
+	if req := ch.Metadata.Dependencies; req != nil {
+		if err := action.CheckDependencies(ch, req); err != nil {
+			return nil, fmt.Errorf("invalid Helm chart %q: %w", chartName, err)
+		}
+	}
+
+	vals, err := helm.valueOptions().MergeValues(getter.All(settings))
+	if err != nil {
+		return nil, err
+	}
+
+	// Do not bother rendering if the read was already abandoned
+	if err := ctx.Err(); err != nil {
+		return nil, err
+	}
+
+	rel, err := install.Run(ch, vals)
+	if err != nil {
+		return nil, fmt.Errorf("unable to render Helm chart %q: %w", chartName, err)
+	}
+
+	// Like `helm template`, include the hooks after the manifest
+	var manifests strings.Builder
+	manifests.WriteString(rel.Manifest)
+	for _, h := range rel.Hooks {
+		_, _ = fmt.Fprintf(&manifests, "\n---\n# Source: %s\n%s\n", h.Path, h.Manifest)
+	}
+
+	return kio.FromBytes([]byte(manifests.String()))
+}
+
+// loadChart locates and loads the chart.
+func (helm *HelmReader) loadChart(ctx context.Context, install *action.Install, chartName string, settings *cli.EnvSettings) (*chart.Chart, error) {
+	if registry.IsOCI(helm.Repository) {
+		result, err := helm.pullChart(ctx)
+		if err != nil {
+			return nil, err
+		}
+
+		ch, err := loader.LoadArchive(bytes.NewReader(result.Chart.Data))
+		if err != nil {
+			return nil, fmt.Errorf("unable to load Helm chart %q: %w", result.Ref, err)
+		}
+		return ch, nil
+	}
+
+	chartPath, err := install.ChartPathOptions.LocateChart(chartName, settings)
+	if err != nil {
+		return nil, fmt.Errorf("unable to locate Helm chart %q: %w", chartName, err)
+	}
+
+	ch, err := loader.Load(chartPath)
+	if err != nil {
+		return nil, fmt.Errorf("unable to load Helm chart %q: %w", chartName, err)
+	}
+	return ch, nil
+}
+
+// pullChart downloads the chart from an OCI registry. When a digest is
+// configured, the chart is pulled by digest and the version is only verified.
+func (helm *HelmReader) pullChart(ctx context.Context) (*registry.PullResult, error) {
+	if helm.pulled != nil {
+		return helm.pulled, nil
+	}
+
+	client, err := helm.registryClient(ctx)
+	if err != nil {
+		return nil, err
+	}
+
+	ref := strings.TrimPrefix(helm.Repository, "oci://") + "/" + helm.Chart
+	if helm.Digest != "" {
+		ref += "@" + helm.Digest
+	} else {
+		tags, err := client.Tags(ref)
+		if err != nil {
+			return nil, fmt.Errorf("unable to list tags for Helm chart %q: %w", ref, err)
+		}
+
+		tag, err := registry.GetTagMatchingVersionOrConstraint(tags, strings.TrimPrefix(helm.Version, "v"))
+		if err != nil {
+			return nil, fmt.Errorf("unable to resolve Helm chart %q: %w", ref, err)
+		}
+		ref += ":" + tag
+	}
+
+	result, err := client.Pull(ref)
+	if err != nil {
+		return nil, fmt.Errorf("unable to pull Helm chart %q: %w", ref, err)
+	}
+
+	if helm.Digest != "" && helm.Version != "" && result.Chart.Meta.Version != strings.TrimPrefix(helm.Version, "v") {
+		return nil, fmt.Errorf("unexpected version %s of Helm chart %q, expected %s", result.Chart.Meta.Version, ref, helm.Version)
+	}
+
+	helm.pulled = result
+	return result, nil
+}
+
+// registryClient returns a new client for OCI registries. Credentials are read
+// from the Helm registry configuration, falling back to the Docker configuration.
+// Requests made by the client are cancelled with the supplied context.
+func (helm *HelmReader) registryClient(ctx context.Context) (*registry.Client, error) {
+	c := http.Client{}
+	if helm.Client != nil {
+		c = *helm.Client
+	}
+	c.Transport = &contextTransport{ctx: ctx, base: c.Transport}
+
+	return registry.NewClient(
+		registry.ClientOptCredentialsFile(helm.settings().RegistryConfig),
+		registry.ClientOptHTTPClient(&c),
+	)
+}
+
+// contextTransport associates every request with a context, the registry
+// client does not accept a context of its own.
+type contextTransport struct {
+	ctx  context.Context
+	base http.RoundTripper
+}
+
+func (t *contextTransport) RoundTrip(req *http.Request) (*http.Response, error) {
+	base := t.base
+	if base == nil {
+		base = http.DefaultTransport
+	}
+	return base.RoundTrip(req.WithContext(t.ctx))
+}
+
+// settings returns the Helm environment settings.
+func (helm *HelmReader) settings() *cli.EnvSettings {
+	settings := cli.New()
+	if helm.RepositoryCache != "" {
+		settings.RepositoryCache = helm.RepositoryCache
+	}
+	if helm.RegistryConfig != "" {
+		settings.RegistryConfig = helm.RegistryConfig
+	}
+	return settings
+}
+
+// valueOptions returns the Helm SDK representation of the chart values.
+func (helm *HelmReader) valueOptions() *values.Options {
+	opts := &values.Options{}
+	for i := range helm.Values {
+		switch {
+
+		case helm.Values[i].File != "":
+			opts.ValueFiles = append(opts.ValueFiles, valueFiles(&helm.Values[i])...)
+
+		case helm.Values[i].Name != "":
+			nameValue := fmt.Sprintf("%s=%v", helm.Values[i].Name, helm.Values[i].Value)
+			if helm.Values[i].LoadFile {
+				opts.FileValues = append(opts.FileValues, nameValue)
+			} else if helm.Values[i].ForceString {
+				opts.StringValues = append(opts.StringValues, nameValue)
+			} else {
+				opts.Values = append(opts.Values, nameValue)
+			}
+
+		}
+	}
+	return opts
+}
+
+// templateCommand returns the `helm template` command for the chart.
+func (helm *HelmReader) templateCommand(ctx context.Context) *command {
+	cmd := helm.command(ctx)
 
 	cmd.Args = append(cmd.Args, "template")
 
@@ -45,7 +326,11 @@ func (helm *HelmReader) Read() ([]*yaml.RNode, error) {
 		cmd.Args = append(cmd.Args, "--generate-name")
 	}
 
-	cmd.Args = append(cmd.Args, helm.Chart)
+	if registry.IsOCI(helm.Repository) {
+		cmd.Args = append(cmd.Args, strings.TrimSuffix(helm.Repository, "/")+"/"+helm.Chart)
+	} else {
+		cmd.Args = append(cmd.Args, helm.Chart)
+	}
 
 	if helm.Version != "" {
 		cmd.Args = append(cmd.Args, "--version", helm.Version)
@@ -55,7 +340,7 @@ func (helm *HelmReader) Read() ([]*yaml.RNode, error) {
 		cmd.Args = append(cmd.Args, "--namespace", helm.ReleaseNamespace)
 	}
 
-	if helm.Repository != "" {
+	if helm.Repository != "" && !registry.IsOCI(helm.Repository) {
 		cmd.Args = append(cmd.Args, "--repo", helm.Repository)
 	}
 
@@ -63,12 +348,7 @@ func (helm *HelmReader) Read() ([]*yaml.RNode, error) {
 		switch {
 
 		case helm.Values[i].File != "":
-			// Try to expand a glob; if it fails or does not match, pass on the raw value and let Helm figure it out
-			valueFiles := []string{helm.Values[i].File}
-			if matches, err := filepath.Glob(helm.Values[i].File); err == nil && len(matches) > 0 {
-				valueFiles = matches
-			}
-			for _, f := range valueFiles {
+			for _, f := range valueFiles(&helm.Values[i]) {
 				cmd.Args = append(cmd.Args, "--values", f)
 			}
 
@@ -85,21 +365,189 @@ func (helm *HelmReader) Read() ([]*yaml.RNode, error) {
 		}
 	}
 
-	p := &filters.Pipeline{Inputs: []kio.Reader{cmd}}
-
-	if !helm.IncludeTests {
-		p.Filters = append(p.Filters, &filters.ResourceMetaFilter{
-			AnnotationSelector: "helm.sh/hook notin (test-success, test-failure)",
-		})
-	}
-
-	return p.Read()
+	return cmd
 }
 
-func (helm *HelmReader) command() *command {
-	cmd := helm.Runtime.command("helm")
+func (helm *HelmReader) command(ctx context.Context) *command {
+	cmd := helm.Runtime.command(ctx, "helm")
 	if helm.RepositoryCache != "" {
 		cmd.Env = append(cmd.Env, "HELM_REPOSITORY_CACHE="+helm.RepositoryCache)
 	}
+	if helm.RegistryConfig != "" {
+		cmd.Env = append(cmd.Env, "HELM_REGISTRY_CONFIG="+helm.RegistryConfig)
+	}
 	return cmd
 }
+
+// lock replaces the requested chart version with the locked version and
+// verifies that the chart has not changed.
+func (helm *HelmReader) lock(ctx context.Context) error {
+	if helm.Lock == nil {
+		return nil
+	}
+
+	// Local charts have no version to resolve
+	if helm.Repository == "" && !registry.IsOCI(helm.Chart) {
+		return nil
+	}
+
+	locked := LockedHelm{
+		Repository: helm.Repository,
+		Chart:      helm.Chart,
+		Constraint: helm.Version,
+	}
+
+	if l, err := helm.Lock.helm(locked.Repository, locked.Chart, locked.Constraint); err != nil {
+		return err
+	} else if l != nil {
+		helm.Version = l.Version
+		if registry.IsOCI(helm.Repository) && helm.Digest == "" {
+			helm.Digest = l.Digest
+		}
+	}
+
+	var err error
+	locked.Version, locked.Digest, err = helm.resolveChart(ctx)
+	if err != nil {
+		// Without a repository index, only an exact version can be locked
+		if _, verr := semver.StrictNewVersion(strings.TrimPrefix(helm.Version, "v")); verr != nil {
+			return fmt.Errorf("unable to lock Helm chart %s: %w", helm.Chart, err)
+		}
+		locked.Version, locked.Digest = helm.Version, ""
+	}
+
+	helm.Version = locked.Version
+	return helm.Lock.verifyHelm(locked)
+}
+
+// cacheKey returns the key used to identify the rendered chart in the cache. An
+// empty key is returned if there is no cache or the chart cannot be resolved to
+// an immutable identity.
+func (helm *HelmReader) cacheKey(ctx context.Context) string {
+	if helm.Cache == nil {
+		return ""
+	}
+
+	version, digest, err := helm.resolveChart(ctx)
+	if err != nil {
+		return ""
+	}
+
+	// Include the contents of the local files referenced by the values
+	var files []string
+	for _, name := range helm.localFiles() {
+		data, err := os.ReadFile(name)
+		if err != nil {
+			return ""
+		}
+		files = append(files, string(data))
+	}
+
+	// The timeout does not change the rendered chart
+	h := helm.Helm
+	h.Timeout = ""
+
+	key, err := helm.Cache.Key(&h, version, digest, files)
+	if err != nil {
+		return ""
+	}
+	return key
+}
+
+// localFiles returns the names of the local files referenced by the values.
+func (helm *HelmReader) localFiles() []string {
+	var names []string
+	for i := range helm.Values {
+		switch {
+		case helm.Values[i].File != "":
+			names = append(names, valueFiles(&helm.Values[i])...)
+		case helm.Values[i].LoadFile:
+			names = append(names, helm.Values[i].Value)
+		}
+	}
+	return names
+}
+
+// resolveChart uses the repository index or OCI registry to find the version
+// and digest of the configured chart.
+func (helm *HelmReader) resolveChart(ctx context.Context) (version string, digest string, err error) {
+	if helm.resolvedVersion != "" {
+		return helm.resolvedVersion, helm.resolvedDigest, nil
+	}
+
+	if registry.IsOCI(helm.Repository) {
+		result, err := helm.pullChart(ctx)
+		if err != nil {
+			return "", "", err
+		}
+		helm.resolvedVersion, helm.resolvedDigest = result.Chart.Meta.Version, result.Manifest.Digest
+		return helm.resolvedVersion, helm.resolvedDigest, nil
+	}
+
+	if !strings.HasPrefix(helm.Repository, "http://") && !strings.HasPrefix(helm.Repository, "https://") {
+		return "", "", fmt.Errorf("unable to resolve chart from repository %q", helm.Repository)
+	}
+
+	c := helm.Client
+	if c == nil {
+		c = http.DefaultClient
+	}
+
+	req, err := http.NewRequestWithContext(ctx, http.MethodGet, strings.TrimSuffix(helm.Repository, "/")+"/index.yaml", nil)
+	if err != nil {
+		return "", "", err
+	}
+
+	resp, err := c.Do(req)
+	if err != nil {
+		return "", "", err
+	}
+	defer resp.Body.Close()
+	if resp.StatusCode != http.StatusOK {
+		return "", "", fmt.Errorf("invalid response code for Helm repository %q: %d", helm.Repository, resp.StatusCode)
+	}
+
+	index := struct {
+		Entries map[string][]struct {
+			Version string `yaml:"version"`
+			Digest  string `yaml:"digest"`
+		} `yaml:"entries"`
+	}{}
+	if err := yaml.NewDecoder(resp.Body).Decode(&index); err != nil {
+		return "", "", err
+	}
+
+	// Without a version, the latest version excluding pre-releases is used
+	constraint := helm.Version
+	if constraint == "" {
+		constraint = "*"
+	}
+	versions, err := semver.NewConstraint(constraint)
+	if err != nil {
+		return "", "", fmt.Errorf("invalid version %q for chart %q: %w", helm.Version, helm.Chart, err)
+	}
+
+	var latest *semver.Version
+	for _, e := range index.Entries[helm.Chart] {
+		v, err := semver.NewVersion(e.Version)
+		if err != nil || !versions.Check(v) || (latest != nil && !v.GreaterThan(latest)) {
+			continue
+		}
+		latest = v
+		helm.resolvedVersion, helm.resolvedDigest = e.Version, e.Digest
+	}
+	if latest == nil {
+		return "", "", fmt.Errorf("unable to resolve chart %q version %q", helm.Chart, helm.Version)
+	}
+
+	return helm.resolvedVersion, helm.resolvedDigest, nil
+}
+
+// valueFiles returns the value file names to use.
+func valueFiles(value *konjurev1beta2.HelmValue) []string {
+	// Try to expand a glob; if it fails or does not match, pass on the raw value and let Helm figure it out
+	if matches, err := filepath.Glob(value.File); err == nil && len(matches) > 0 {
+		return matches
+	}
+	return []string{value.File}
+}
@@ -0,0 +1,483 @@
+/*
+Copyright 2023 GramLabs, Inc.
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package readers
+
+import (
+	"context"
+	"encoding/json"
+	"net/http"
+	"net/http/httptest"
+	"os"
+	"path/filepath"
+	"strconv"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/opencontainers/go-digest"
+	specs "github.com/opencontainers/image-spec/specs-go"
+	ocispec "github.com/opencontainers/image-spec/specs-go/v1"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+	konjurev1beta2 "github.com/thestormforge/konjure/pkg/api/core/v1beta2"
+	"helm.sh/helm/v3/pkg/chart/loader"
+	"helm.sh/helm/v3/pkg/chartutil"
+	"helm.sh/helm/v3/pkg/registry"
+)
+
+func TestHelmReader_Read(t *testing.T) {
+	chart := t.TempDir()
+	writeFiles(t, chart, map[string]string{
+		"Chart.yaml": `apiVersion: v2
+name: example
+version: 0.1.0
+`,
+		"values.yaml": `greeting: hello
+`,
+		"templates/configmap.yaml": `apiVersion: v1
+kind: ConfigMap
+metadata:
+  name: {{ .Release.Name }}-config
+  namespace: {{ .Release.Namespace }}
+data:
+  greeting: {{ .Values.greeting | quote }}
+  enabled: {{ .Values.enabled | quote }}
+`,
+		"templates/tests/test.yaml": `apiVersion: v1
+kind: Pod
+metadata:
+  name: {{ .Release.Name }}-test
+  annotations:
+    helm.sh/hook: test
+`,
+	})
+
+	cases := []struct {
+		desc         string
+		values       []konjurev1beta2.HelmValue
+		includeTests bool
+		expected     map[string]string
+	}{
+		{
+			desc:     "defaults",
+			expected: map[string]string{"test-config": "hello"},
+		},
+		{
+			desc: "set values",
+			values: []konjurev1beta2.HelmValue{
+				{Name: "greeting", Value: "goodbye"},
+				{Name: "enabled", Value: "true", ForceString: true},
+			},
+			expected: map[string]string{"test-config": "goodbye"},
+		},
+		{
+			desc:         "include tests",
+			includeTests: true,
+			expected:     map[string]string{"test-config": "hello", "test-test": ""},
+		},
+	}
+	for _, c := range cases {
+		t.Run(c.desc, func(t *testing.T) {
+			r := &HelmReader{
+				Helm: konjurev1beta2.Helm{
+					Chart:            chart,
+					ReleaseName:      "test",
+					ReleaseNamespace: "example",
+					Values:           c.values,
+					IncludeTests:     c.includeTests,
+				},
+			}
+
+			nodes, err := r.Read()
+			require.NoError(t, err)
+
+			actual := make(map[string]string, len(nodes))
+			for _, node := range nodes {
+				actual[node.GetName()] = node.GetDataMap()["greeting"]
+			}
+			assert.Equal(t, c.expected, actual)
+		})
+	}
+}
+
+func TestHelmReader_Read_localLock(t *testing.T) {
+	chart := t.TempDir()
+	writeFiles(t, chart, map[string]string{
+		"Chart.yaml": `apiVersion: v2
+name: example
+version: 0.1.0
+`,
+		"templates/configmap.yaml": `apiVersion: v1
+kind: ConfigMap
+metadata:
+  name: {{ .Release.Name }}-config
+`,
+	})
+
+	lock := &Lock{Update: true}
+	r := &HelmReader{
+		Helm: konjurev1beta2.Helm{
+			Chart:       chart,
+			ReleaseName: "test",
+		},
+		Lock: lock,
+	}
+
+	nodes, err := r.Read()
+	require.NoError(t, err)
+	assert.Len(t, nodes, 1)
+	assert.Empty(t, lock.Helm)
+}
+
+func TestHelmReader_resolveChart(t *testing.T) {
+	// Entries are intentionally not sorted
+	srv := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		if r.URL.Path != "/index.yaml" {
+			http.NotFound(w, r)
+			return
+		}
+		_, _ = w.Write([]byte(`apiVersion: v1
+entries:
+  example:
+  - version: 1.2.0
+    digest: sha256:120
+  - version: 1.10.0
+    digest: sha256:1100
+  - version: 2.0.0-rc.1
+    digest: sha256:200rc1
+  - version: 1.2.5
+    digest: sha256:125
+`))
+	}))
+	t.Cleanup(srv.Close)
+
+	cases := []struct {
+		desc    string
+		version string
+		digest  string
+		err     string
+	}{
+		{
+			desc:   "latest",
+			digest: "sha256:1100",
+		},
+		{
+			desc:    "exact",
+			version: "1.2.0",
+			digest:  "sha256:120",
+		},
+		{
+			desc:    "constraint",
+			version: "~1.2",
+			digest:  "sha256:125",
+		},
+		{
+			desc:    "wildcard",
+			version: "1.x",
+			digest:  "sha256:1100",
+		},
+		{
+			desc:    "missing",
+			version: "^3",
+			err:     `unable to resolve chart "example" version "^3"`,
+		},
+	}
+	for _, c := range cases {
+		t.Run(c.desc, func(t *testing.T) {
+			r := &HelmReader{Helm: konjurev1beta2.Helm{Repository: srv.URL, Chart: "example", Version: c.version}}
+			_, digest, err := r.resolveChart(context.Background())
+			if c.err != "" {
+				assert.EqualError(t, err, c.err)
+				return
+			}
+			if assert.NoError(t, err) {
+				assert.Equal(t, c.digest, digest)
+			}
+		})
+	}
+}
+
+func TestHelmReader_lock(t *testing.T) {
+	// A repository without an index cannot resolve versions
+	srv := httptest.NewServer(http.NotFoundHandler())
+	t.Cleanup(srv.Close)
+
+	cases := []struct {
+		desc    string
+		version string
+		err     string
+	}{
+		{
+			desc:    "exact version",
+			version: "1.2.3",
+		},
+		{
+			desc:    "constraint",
+			version: "^1.2",
+			err:     "unable to lock Helm chart example",
+		},
+		{
+			desc:    "wildcard",
+			version: "1.x",
+			err:     "unable to lock Helm chart example",
+		},
+		{
+			desc: "no version",
+			err:  "unable to lock Helm chart example",
+		},
+	}
+	for _, c := range cases {
+		t.Run(c.desc, func(t *testing.T) {
+			lock := &Lock{Update: true}
+			r := &HelmReader{Helm: konjurev1beta2.Helm{Repository: srv.URL, Chart: "example", Version: c.version}, Lock: lock}
+			err := r.lock(context.Background())
+			if c.err != "" {
+				assert.ErrorContains(t, err, c.err)
+				assert.Empty(t, lock.Helm)
+				return
+			}
+			if assert.NoError(t, err) && assert.Len(t, lock.Helm, 1) {
+				assert.Equal(t, c.version, lock.Helm[0].Version)
+			}
+		})
+	}
+}
+
+func TestHelmReader_Read_templateError(t *testing.T) {
+	chart := t.TempDir()
+	writeFiles(t, chart, map[string]string{
+		"Chart.yaml": `apiVersion: v2
+name: broken
+version: 0.1.0
+`,
+		"templates/broken.yaml": `{{ required "missing value" .Values.missing }}
+`,
+	})
+
+	r := &HelmReader{Helm: konjurev1beta2.Helm{Chart: chart, ReleaseName: "test"}}
+
+	_, err := r.Read()
+	if assert.Error(t, err) {
+		assert.Contains(t, err.Error(), "broken/templates/broken.yaml")
+		assert.Contains(t, err.Error(), "missing value")
+	}
+}
+
+func writeFiles(t *testing.T, dir string, files map[string]string) {
+	for name, content := range files {
+		path := filepath.Join(dir, name)
+		require.NoError(t, os.MkdirAll(filepath.Dir(path), 0755))
+		require.NoError(t, os.WriteFile(path, []byte(content), 0644))
+	}
+}
+
+func TestHelmReader_Read_oci(t *testing.T) {
+	chart := t.TempDir()
+	writeFiles(t, chart, map[string]string{
+		"Chart.yaml": `apiVersion: v2
+name: example
+version: 1.2.3
+`,
+		"templates/configmap.yaml": `apiVersion: v1
+kind: ConfigMap
+metadata:
+  name: {{ .Release.Name }}-{{ .Chart.Version }}
+`,
+	})
+
+	reg := newTestRegistry(t)
+	manifestDigest := reg.pushChart(t, "charts/example", chart)
+	repo := "oci://" + strings.TrimPrefix(reg.URL, "http://") + "/charts"
+
+	cases := []struct {
+		desc     string
+		version  string
+		digest   string
+		expected string
+		err      string
+	}{
+		{
+			desc:     "latest",
+			expected: "test-1.2.3",
+		},
+		{
+			desc:     "version",
+			version:  "1.2.3",
+			expected: "test-1.2.3",
+		},
+		{
+			desc:     "constraint",
+			version:  "^1.0.0",
+			expected: "test-1.2.3",
+		},
+		{
+			desc:     "digest",
+			digest:   manifestDigest,
+			expected: "test-1.2.3",
+		},
+		{
+			desc:    "digest version mismatch",
+			version: "1.0.0",
+			digest:  manifestDigest,
+			err:     "unexpected version 1.2.3",
+		},
+		{
+			desc:    "missing version",
+			version: "2.0.0",
+			err:     "unable to resolve Helm chart",
+		},
+	}
+	for _, c := range cases {
+		t.Run(c.desc, func(t *testing.T) {
+			r := &HelmReader{
+				Helm: konjurev1beta2.Helm{
+					Repository:  repo,
+					Chart:       "example",
+					Version:     c.version,
+					Digest:      c.digest,
+					ReleaseName: "test",
+				},
+				RegistryConfig: filepath.Join(t.TempDir(), "config.json"),
+			}
+
+			nodes, err := r.Read()
+			if c.err != "" {
+				if assert.Error(t, err) {
+					assert.Contains(t, err.Error(), c.err)
+				}
+				return
+			}
+			if assert.NoError(t, err) && assert.Len(t, nodes, 1) {
+				assert.Equal(t, c.expected, nodes[0].GetName())
+			}
+		})
+	}
+}
+
+func TestHelmReader_Read_timeout(t *testing.T) {
+	// A registry that never responds
+	reg := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		<-r.Context().Done()
+	}))
+	t.Cleanup(reg.Close)
+
+	lock := &Lock{Update: true}
+	r := &HelmReader{
+		Helm: konjurev1beta2.Helm{
+			Repository:  "oci://" + strings.TrimPrefix(reg.URL, "http://") + "/charts",
+			Chart:       "example",
+			ReleaseName: "test",
+			Timeout:     "100ms",
+		},
+		RegistryConfig: filepath.Join(t.TempDir(), "config.json"),
+		Cache:          &Cache{Dir: t.TempDir()},
+		Lock:           lock,
+	}
+
+	start := time.Now()
+	_, err := r.Read()
+	if assert.Error(t, err) {
+		assert.Contains(t, err.Error(), context.DeadlineExceeded.Error())
+	}
+	assert.Less(t, time.Since(start), 5*time.Second)
+	assert.Empty(t, lock.Helm)
+}
+
+// testRegistry is a minimal, in-memory stand-in for an OCI distribution registry.
+type testRegistry struct {
+	*httptest.Server
+	blobs     map[string][]byte
+	manifests map[string][]byte
+	tags      map[string][]string
+}
+
+func newTestRegistry(t *testing.T) *testRegistry {
+	reg := &testRegistry{
+		blobs:     make(map[string][]byte),
+		manifests: make(map[string][]byte),
+		tags:      make(map[string][]string),
+	}
+	reg.Server = httptest.NewServer(reg)
+	t.Cleanup(reg.Close)
+	return reg
+}
+
+func (reg *testRegistry) ServeHTTP(w http.ResponseWriter, r *http.Request) {
+	p := strings.TrimPrefix(r.URL.Path, "/v2/")
+	if p == "" {
+		return
+	}
+
+	if name, ok := strings.CutSuffix(p, "/tags/list"); ok {
+		w.Header().Set("Content-Type", "application/json")
+		_ = json.NewEncoder(w).Encode(map[string]interface{}{"name": name, "tags": reg.tags[name]})
+		return
+	}
+
+	var data []byte
+	var ok bool
+	if pos := strings.LastIndex(p, "/manifests/"); pos > 0 {
+		data, ok = reg.manifests[p[:pos]+"@"+p[pos+len("/manifests/"):]]
+		w.Header().Set("Content-Type", ocispec.MediaTypeImageManifest)
+	} else if pos := strings.LastIndex(p, "/blobs/"); pos > 0 {
+		data, ok = reg.blobs[p[pos+len("/blobs/"):]]
+		w.Header().Set("Content-Type", "application/octet-stream")
+	}
+	if !ok {
+		http.NotFound(w, r)
+		return
+	}
+
+	w.Header().Set("Docker-Content-Digest", digest.FromBytes(data).String())
+	w.Header().Set("Content-Length", strconv.Itoa(len(data)))
+	if r.Method != http.MethodHead {
+		_, _ = w.Write(data)
+	}
+}
+
+// pushChart packages the chart directory and stores it in the registry, returning the manifest digest.
+func (reg *testRegistry) pushChart(t *testing.T, name, dir string) string {
+	ch, err := loader.Load(dir)
+	require.NoError(t, err)
+	archive, err := chartutil.Save(ch, t.TempDir())
+	require.NoError(t, err)
+	chartData, err := os.ReadFile(archive)
+	require.NoError(t, err)
+	configData, err := json.Marshal(ch.Metadata)
+	require.NoError(t, err)
+
+	manifest := ocispec.Manifest{
+		Versioned: specs.Versioned{SchemaVersion: 2},
+		MediaType: ocispec.MediaTypeImageManifest,
+		Config:    reg.putBlob(registry.ConfigMediaType, configData),
+		Layers:    []ocispec.Descriptor{reg.putBlob(registry.ChartLayerMediaType, chartData)},
+	}
+	manifestData, err := json.Marshal(&manifest)
+	require.NoError(t, err)
+
+	manifestDigest := digest.FromBytes(manifestData).String()
+	reg.manifests[name+"@"+ch.Metadata.Version] = manifestData
+	reg.manifests[name+"@"+manifestDigest] = manifestData
+	reg.tags[name] = append(reg.tags[name], ch.Metadata.Version)
+	return manifestDigest
+}
+
+func (reg *testRegistry) putBlob(mediaType string, data []byte) ocispec.Descriptor {
+	d := digest.FromBytes(data)
+	reg.blobs[d.String()] = data
+	return ocispec.Descriptor{MediaType: mediaType, Digest: d, Size: int64(len(data))}
+}
@@ -17,8 +17,14 @@ limitations under the License.
 package readers
 
 import (
+	"bytes"
+	"context"
+	"crypto/sha256"
+	"encoding/hex"
 	"fmt"
+	"io"
 	"net/http"
+	"strings"
 
 	konjurev1beta2 "github.com/thestormforge/konjure/pkg/api/core/v1beta2"
 	"sigs.k8s.io/kustomize/kyaml/kio"
@@ -28,16 +34,35 @@ import (
 type HTTPReader struct {
 	konjurev1beta2.HTTP
 	Client *http.Client
+	// The cache used to avoid re-fetching unmodified resources.
+	Cache *Cache
+	// The lock used to verify the content has not changed.
+	Lock *Lock
+
+	ctx context.Context
 }
 
 func (r *HTTPReader) Read() ([]*yaml.RNode, error) {
-	req, err := http.NewRequest(http.MethodGet, r.HTTP.URL, nil)
+	ctx, cancel, err := readContext(r.ctx, r.Timeout)
+	if err != nil {
+		return nil, err
+	}
+	defer cancel()
+
+	req, err := http.NewRequestWithContext(ctx, http.MethodGet, r.HTTP.URL, nil)
 	if err != nil {
 		return nil, err
 	}
 
 	// TODO Set Accept headers for JSON or YAML
 
+	// Use a previously recorded entity tag to make the request conditional
+	key := r.cacheKey()
+	etag, digest, _ := strings.Cut(r.Cache.GetTag("http", key), "\n")
+	if etag != "" {
+		req.Header.Set("If-None-Match", etag)
+	}
+
 	c := r.Client
 	if c == nil {
 		c = http.DefaultClient
@@ -49,10 +74,60 @@ func (r *HTTPReader) Read() ([]*yaml.RNode, error) {
 	}
 
 	defer resp.Body.Close()
+	if resp.StatusCode == http.StatusNotModified && etag != "" {
+		if nodes, err := r.Cache.Get("http", strings.TrimPrefix(digest, "sha256:")); err != nil || nodes != nil {
+			if err == nil {
+				err = r.Lock.verifyHTTP(r.HTTP.URL, digest)
+			}
+			return nodes, err
+		}
+
+		// The cache entry is gone, request the full resource again
+		r.Cache = nil
+		return r.Read()
+	}
 	if resp.StatusCode < 200 || resp.StatusCode >= 300 {
 		return nil, fmt.Errorf("invalid response code for %q: %d", r.HTTP.URL, resp.StatusCode)
 	}
 
-	// TODO Should we annotate where the (likely non-Konjure) resources originated from? Even if the default writer strips those annotations
-	return (&kio.ByteReader{Reader: resp.Body}).Read()
+	data, err := io.ReadAll(resp.Body)
+	if err != nil {
+		return nil, err
+	}
+
+	sum := sha256.Sum256(data)
+	digest = "sha256:" + hex.EncodeToString(sum[:])
+	if err := r.Lock.verifyHTTP(r.HTTP.URL, digest); err != nil {
+		return nil, err
+	}
+
+	nodes, err := (&kio.ByteReader{Reader: bytes.NewReader(data)}).Read()
+	if err != nil {
+		return nil, err
+	}
+
+	// Only cache responses which can be validated later
+	if etag := resp.Header.Get("ETag"); etag != "" {
+		if err := r.Cache.Put("http", hex.EncodeToString(sum[:]), nodes); err != nil {
+			return nil, err
+		}
+		if err := r.Cache.PutTag("http", key, etag+"\n"+digest); err != nil {
+			return nil, err
+		}
+	}
+
+	return nodes, nil
+}
+
+// cacheKey returns the key used to identify the resource in the cache.
+func (r *HTTPReader) cacheKey() string {
+	if r.Cache == nil {
+		return ""
+	}
+
+	key, err := r.Cache.Key(r.HTTP.URL)
+	if err != nil {
+		return ""
+	}
+	return key
 }
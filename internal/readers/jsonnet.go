@@ -17,6 +17,7 @@ limitations under the License.
 package readers
 
 import (
+	"context"
 	"encoding/json"
 	"fmt"
 	"io/ioutil"
@@ -48,6 +49,7 @@ func NewJsonnetReader(js *konjurev1beta2.Jsonnet) kio.Reader {
 		},
 		Filename: js.Filename,
 		Snippet:  js.Code,
+		Timeout:  js.Timeout,
 	}
 
 	// Setup Jsonnet Bundler
@@ -79,9 +81,23 @@ type JsonnetReader struct {
 	MakeVM                    func() *jsonnet.VM
 	Filename                  string
 	Snippet                   string
+	Timeout                   string
+	Tracker                   *Tracker
+
+	ctx context.Context
 }
 
 func (r *JsonnetReader) Read() ([]*yaml.RNode, error) {
+	ctx, cancel, err := readContext(r.ctx, r.Timeout)
+	if err != nil {
+		return nil, err
+	}
+	defer cancel()
+
+	return readAsync(ctx, r.read)
+}
+
+func (r *JsonnetReader) read() ([]*yaml.RNode, error) {
 	// Before we start, make sure the bundler is up-to-date (i.e. download `/vendor/`)
 	if r.JsonnetBundlerPackageHome != "" {
 		if err := r.bundlerEnsure(); err != nil {
@@ -103,6 +119,7 @@ func (r *JsonnetReader) Read() ([]*yaml.RNode, error) {
 	var data string
 	var err error
 	if r.Filename != "" {
+		r.Tracker.Track(r.Filename)
 		data, err = vm.EvaluateFile(r.Filename)
 	} else {
 		filename := "<cmdline>"
@@ -119,7 +136,11 @@ func (r *JsonnetReader) Read() ([]*yaml.RNode, error) {
 }
 
 func (r *JsonnetReader) Import(importedFrom, importedPath string) (jsonnet.Contents, string, error) {
-	return r.FileImporter.Import(importedFrom, importedPath)
+	contents, foundAt, err := r.FileImporter.Import(importedFrom, importedPath)
+	if err == nil {
+		r.Tracker.Track(foundAt)
+	}
+	return contents, foundAt, err
 }
 
 // bundlerEnsure runs the Jsonnet bundler to ensure any dependencies are present.
@@ -17,20 +17,33 @@ limitations under the License.
 package readers
 
 import (
-	"bufio"
-	"bytes"
+	"context"
 	"fmt"
-	"path"
+	"sort"
 	"strings"
 
 	konjurev1beta2 "github.com/thestormforge/konjure/pkg/api/core/v1beta2"
 	"github.com/thestormforge/konjure/pkg/filters"
+	"golang.org/x/sync/errgroup"
+	"k8s.io/apimachinery/pkg/api/meta"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	"k8s.io/apimachinery/pkg/apis/meta/v1/unstructured"
+	"k8s.io/apimachinery/pkg/runtime"
+	"k8s.io/apimachinery/pkg/runtime/schema"
+	"k8s.io/apimachinery/pkg/util/sets"
+	"k8s.io/client-go/discovery"
+	"k8s.io/client-go/discovery/cached/memory"
+	"k8s.io/client-go/dynamic"
+	"k8s.io/client-go/rest"
+	"k8s.io/client-go/restmapper"
+	"k8s.io/client-go/tools/clientcmd"
+	"k8s.io/client-go/tools/pager"
 	"sigs.k8s.io/kustomize/kyaml/yaml"
 )
 
+// KubernetesReader reads resources from a Kubernetes cluster using the dynamic client.
 type KubernetesReader struct {
 	konjurev1beta2.Kubernetes
-	Runtime
 
 	// Override the default path to the kubeconfig file.
 	Kubeconfig string
@@ -38,60 +51,175 @@ type KubernetesReader struct {
 	Context string
 	// The list of default types to use if none are specified.
 	DefaultTypes []string
+	// Explicit client configuration to use instead of the kubeconfig file.
+	RESTConfig *rest.Config
+	// The maximum number of resources to request at a time, defaults to 500.
+	ChunkSize int64
+	// The maximum number of list requests to make concurrently, defaults to 10.
+	Concurrency int
+
+	ctx context.Context
 }
 
 func (k *KubernetesReader) Read() ([]*yaml.RNode, error) {
-	p := &filters.Pipeline{}
+	ctx, cancel, err := readContext(k.ctx, k.Timeout)
+	if err != nil {
+		return nil, err
+	}
+	defer cancel()
+
+	cfg, defaultNamespace, err := k.clientConfig()
+	if err != nil {
+		return nil, err
+	}
+
+	dc, err := discovery.NewDiscoveryClientForConfig(cfg)
+	if err != nil {
+		return nil, err
+	}
+	client, err := dynamic.NewForConfig(cfg)
+	if err != nil {
+		return nil, err
+	}
+
+	cached := memory.NewMemCacheClient(dc)
+	mapper := restmapper.NewShortcutExpander(restmapper.NewDeferredDiscoveryRESTMapper(cached), cached)
 
 	var namespaces []string
 	if k.AllNamespaces {
-		namespaces = []string{""}
-	} else if ns, err := k.namespaces(); err != nil {
+		namespaces = []string{metav1.NamespaceAll}
+	} else if ns, err := k.namespaces(ctx, client, defaultNamespace); err != nil {
 		return nil, err
 	} else {
 		namespaces = ns
 	}
 
-	types, err := k.types()
+	mappings, err := k.mappings(cached, mapper)
 	if err != nil {
 		return nil, err
 	}
 
-	for _, ns := range namespaces {
-		cmd := k.command()
-		cmd.Args = append(cmd.Args, "get")
-		cmd.Args = append(cmd.Args, "--ignore-not-found")
-		cmd.Args = append(cmd.Args, "--output", "yaml")
-		cmd.Args = append(cmd.Args, "--selector", k.Selector)
-		cmd.Args = append(cmd.Args, "--field-selector", k.FieldSelector)
-
-		if k.AllNamespaces {
-			cmd.Args = append(cmd.Args, "--all-namespaces")
+	// List each type in each namespace concurrently, retaining the results by index to preserve ordering
+	type list struct {
+		resource  dynamic.NamespaceableResourceInterface
+		namespace string
+	}
+	var lists []list
+	for i, ns := range namespaces {
+		for _, m := range mappings {
+			switch {
+			case m.Scope.Name() == meta.RESTScopeNameNamespace:
+				lists = append(lists, list{resource: client.Resource(m.Resource), namespace: ns})
+			case i == 0:
+				// Cluster scoped resources are only listed once
+				lists = append(lists, list{resource: client.Resource(m.Resource)})
+			}
 		}
-		if ns != "" {
-			cmd.Args = append(cmd.Args, "--namespace", ns)
+	}
+
+	opts := metav1.ListOptions{
+		LabelSelector: k.Selector,
+		FieldSelector: k.FieldSelector,
+	}
+
+	g, gctx := errgroup.WithContext(ctx)
+	g.SetLimit(k.concurrency())
+	listed := make([][]*unstructured.Unstructured, len(lists))
+	for i := range lists {
+		i := i
+		g.Go(func() (err error) {
+			listed[i], err = k.list(gctx, lists[i].resource.Namespace(lists[i].namespace), opts)
+			return
+		})
+	}
+	if err := g.Wait(); err != nil {
+		return nil, err
+	}
+
+	var objs []*unstructured.Unstructured
+	for _, items := range listed {
+		objs = append(objs, items...)
+	}
+
+	// Add the objects related to the selected objects
+	if k.FollowReferences > 0 {
+		rf := &referenceFollower{client: client, mapper: mapper, list: k.list}
+		related, err := rf.follow(ctx, objs, k.FollowReferences)
+		if err != nil {
+			return nil, err
 		}
+		objs = append(objs, related...)
+	}
 
-		cmd.Args = append(cmd.Args, strings.Join(types, ","))
+	result := make([]*yaml.RNode, 0, len(objs))
+	for _, obj := range objs {
+		// Like kubectl, managed fields are not included
+		unstructured.RemoveNestedField(obj.Object, "metadata", "managedFields")
 
-		p.Inputs = append(p.Inputs, cmd)
+		node, err := yaml.FromMap(obj.Object)
+		if err != nil {
+			return nil, err
+		}
+		result = append(result, node)
 	}
 
-	return p.Read()
+	export := &filters.ExportFilter{Enabled: k.Export, RemoveDefaults: k.RemoveDefaults}
+	return export.Filter(result)
 }
 
-func (k *KubernetesReader) command() *command {
-	cmd := k.Runtime.command("kubectl")
-	if k.Kubeconfig != "" {
-		cmd.Args = append(cmd.Args, "--kubeconfig", k.Kubeconfig)
+// clientConfig returns the client configuration and the default namespace.
+func (k *KubernetesReader) clientConfig() (*rest.Config, string, error) {
+	if k.RESTConfig != nil {
+		return k.RESTConfig, metav1.NamespaceDefault, nil
+	}
+
+	rules := clientcmd.NewDefaultClientConfigLoadingRules()
+	rules.ExplicitPath = k.Kubeconfig
+	overrides := &clientcmd.ConfigOverrides{CurrentContext: k.Context}
+	cc := clientcmd.NewNonInteractiveDeferredLoadingClientConfig(rules, overrides)
+
+	cfg, err := cc.ClientConfig()
+	if err != nil {
+		return nil, "", err
 	}
-	if k.Context != "" {
-		cmd.Args = append(cmd.Args, "--context", k.Context)
+
+	// Match the kubectl defaults so many namespaces do not get throttled
+	if cfg.QPS == 0 && cfg.Burst == 0 {
+		cfg.QPS, cfg.Burst = 50, 300
 	}
-	return cmd
+
+	ns, _, err := cc.Namespace()
+	if err != nil {
+		return nil, "", err
+	}
+
+	return cfg, ns, nil
 }
 
-func (k *KubernetesReader) namespaces() ([]string, error) {
+// list returns all the resources from a single list request, one page at a time.
+func (k *KubernetesReader) list(ctx context.Context, ri dynamic.ResourceInterface, opts metav1.ListOptions) ([]*unstructured.Unstructured, error) {
+	p := pager.New(func(ctx context.Context, opts metav1.ListOptions) (runtime.Object, error) {
+		return ri.List(ctx, opts)
+	})
+	p.PageSize = k.chunkSize()
+
+	var result []*unstructured.Unstructured
+	err := p.EachListItem(ctx, opts, func(obj runtime.Object) error {
+		u, ok := obj.(*unstructured.Unstructured)
+		if !ok {
+			return fmt.Errorf("unexpected list item type %T", obj)
+		}
+		result = append(result, u)
+		return nil
+	})
+	if err != nil {
+		return nil, err
+	}
+
+	return result, nil
+}
+
+func (k *KubernetesReader) namespaces(ctx context.Context, client dynamic.Interface, defaultNamespace string) ([]string, error) {
 	if k.Namespace != "" {
 		return []string{k.Namespace}, nil
 	}
@@ -101,28 +229,102 @@ func (k *KubernetesReader) namespaces() ([]string, error) {
 	}
 
 	if k.NamespaceSelector == "" {
-		return []string{""}, nil
+		return []string{defaultNamespace}, nil
 	}
 
-	cmd := k.command()
-	cmd.Args = append(cmd.Args, "get")
-	cmd.Args = append(cmd.Args, "namespace")
-	cmd.Args = append(cmd.Args, "--selector", k.NamespaceSelector)
-	cmd.Args = append(cmd.Args, "--output", "name")
-	out, err := cmd.Output()
+	list, err := client.Resource(schema.GroupVersionResource{Version: "v1", Resource: "namespaces"}).
+		List(ctx, metav1.ListOptions{LabelSelector: k.NamespaceSelector})
 	if err != nil {
 		return nil, err
 	}
 
 	var namespaces []string
-	scanner := bufio.NewScanner(bytes.NewReader(out))
-	for scanner.Scan() {
-		namespaces = append(namespaces, path.Base(scanner.Text()))
+	for _, item := range list.Items {
+		namespaces = append(namespaces, item.GetName())
 	}
 
 	return namespaces, nil
 }
 
+// mappings resolves the types to read, expanding wildcards and categories using discovery.
+func (k *KubernetesReader) mappings(dc discovery.CachedDiscoveryInterface, mapper meta.RESTMapper) ([]*meta.RESTMapping, error) {
+	types, err := k.types()
+	if err != nil {
+		return nil, err
+	}
+
+	excluded := k.excluded()
+	categories := restmapper.NewDiscoveryCategoryExpander(dc)
+	seen := make(map[schema.GroupVersionResource]struct{})
+	var result []*meta.RESTMapping
+	add := func(m *meta.RESTMapping) {
+		if _, ok := seen[m.Resource]; !ok {
+			seen[m.Resource] = struct{}{}
+			result = append(result, m)
+		}
+	}
+
+	for _, t := range types {
+		if t == "*" {
+			ms, err := discoverMappings(dc)
+			if err != nil {
+				return nil, err
+			}
+			for _, m := range ms {
+				if !excluded(m.Resource.GroupResource()) {
+					add(m)
+				}
+			}
+			continue
+		}
+
+		if grs, ok := categories.Expand(t); ok {
+			for _, gr := range grs {
+				if excluded(gr) {
+					continue
+				}
+				m, err := mappingFor(mapper, gr.String())
+				if err != nil {
+					return nil, err
+				}
+				add(m)
+			}
+			continue
+		}
+
+		m, err := mappingFor(mapper, t)
+		if err != nil {
+			return nil, err
+		}
+		add(m)
+	}
+
+	return result, nil
+}
+
+// excluded returns a function for testing if a discovered resource should be excluded. Exclusions
+// without a group match resources of the same name in any group.
+func (k *KubernetesReader) excluded() func(schema.GroupResource) bool {
+	excludeTypes := k.ExcludeTypes
+	if len(excludeTypes) == 0 {
+		excludeTypes = []string{"events", "endpoints", "endpointslices.discovery.k8s.io", "leases.coordination.k8s.io"}
+	}
+
+	exclusions := make(map[schema.GroupResource]bool, len(excludeTypes))
+	for _, t := range excludeTypes {
+		gr := schema.ParseGroupResource(t)
+		exclusions[gr] = gr.Group == ""
+	}
+
+	return func(gr schema.GroupResource) bool {
+		if _, ok := exclusions[gr]; ok {
+			return true
+		}
+		anyGroup, ok := exclusions[schema.GroupResource{Resource: gr.Resource}]
+		return ok && anyGroup
+	}
+}
+
 func (k *KubernetesReader) types() ([]string, error) {
 	var types []string
 	for _, t := range k.Types {
@@ -146,3 +348,99 @@ func (k *KubernetesReader) types() ([]string, error) {
 
 	return nil, fmt.Errorf("no types specified")
 }
+
+// chunkSize returns the effective page size for list requests.
+func (k *KubernetesReader) chunkSize() int64 {
+	if k.ChunkSize > 0 {
+		return k.ChunkSize
+	}
+	return 500
+}
+
+// concurrency returns the effective number of concurrent list requests.
+func (k *KubernetesReader) concurrency() int {
+	if k.Concurrency > 0 {
+		return k.Concurrency
+	}
+	return 10
+}
+
+// discoverMappings returns the preferred version of every listable resource on the server.
+func discoverMappings(dc discovery.DiscoveryInterface) ([]*meta.RESTMapping, error) {
+	lists, err := dc.ServerPreferredResources()
+	if err != nil && !discovery.IsGroupDiscoveryFailedError(err) {
+		return nil, err
+	}
+
+	// Unavailable API groups (e.g. a failing aggregated API server) are skipped
+	var result []*meta.RESTMapping
+	for _, list := range lists {
+		gv, err := schema.ParseGroupVersion(list.GroupVersion)
+		if err != nil {
+			return nil, err
+		}
+
+		for _, r := range list.APIResources {
+			if strings.Contains(r.Name, "/") || !sets.New(r.Verbs...).Has("list") {
+				continue
+			}
+
+			scope := meta.RESTScopeRoot
+			if r.Namespaced {
+				scope = meta.RESTScopeNamespace
+			}
+
+			result = append(result, &meta.RESTMapping{
+				Resource:         gv.WithResource(r.Name),
+				GroupVersionKind: gv.WithKind(r.Kind),
+				Scope:            scope,
+			})
+		}
+	}
+
+	// The discovery order is not stable
+	sort.Slice(result, func(i, j int) bool {
+		if result[i].Resource.Group != result[j].Resource.Group {
+			return result[i].Resource.Group < result[j].Resource.Group
+		}
+		return result[i].Resource.Resource < result[j].Resource.Resource
+	})
+
+	return result, nil
+}
+
+// mappingFor resolves a resource or kind argument (e.g. "deploy", "deployments.apps" or "Deployment")
+// the same way kubectl does.
+func mappingFor(mapper meta.RESTMapper, resourceOrKind string) (*meta.RESTMapping, error) {
+	fullySpecifiedGVR, groupResource := schema.ParseResourceArg(resourceOrKind)
+	gvk := schema.GroupVersionKind{}
+	if fullySpecifiedGVR != nil {
+		gvk, _ = mapper.KindFor(*fullySpecifiedGVR)
+	}
+	if gvk.Empty() {
+		gvk, _ = mapper.KindFor(groupResource.WithVersion(""))
+	}
+	if !gvk.Empty() {
+		return mapper.RESTMapping(gvk.GroupKind(), gvk.Version)
+	}
+
+	fullySpecifiedGVK, groupKind := schema.ParseKindArg(resourceOrKind)
+	if fullySpecifiedGVK == nil {
+		gvk := groupKind.WithVersion("")
+		fullySpecifiedGVK = &gvk
+	}
+	if !fullySpecifiedGVK.Empty() {
+		if mapping, err := mapper.RESTMapping(fullySpecifiedGVK.GroupKind(), fullySpecifiedGVK.Version); err == nil {
+			return mapping, nil
+		}
+	}
+
+	mapping, err := mapper.RESTMapping(groupKind, gvk.Version)
+	if err != nil {
+		if meta.IsNoMatchError(err) {
+			return nil, fmt.Errorf("the server doesn't have a resource type %q", groupResource.Resource)
+		}
+		return nil, err
+	}
+	return mapping, nil
+}
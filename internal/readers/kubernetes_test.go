@@ -0,0 +1,342 @@
+/*
+Copyright 2023 GramLabs, Inc.
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package readers
+
+import (
+	"encoding/json"
+	"net/http"
+	"net/http/httptest"
+	"strconv"
+	"strings"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	konjurev1beta2 "github.com/thestormforge/konjure/pkg/api/core/v1beta2"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	"k8s.io/apimachinery/pkg/labels"
+	"k8s.io/client-go/rest"
+)
+
+func TestKubernetesReader_Read(t *testing.T) {
+	srv := httptest.NewServer(newFakeAPIServer(t))
+	defer srv.Close()
+
+	cases := []struct {
+		desc       string
+		kubernetes konjurev1beta2.Kubernetes
+		expected   []string
+		err        string
+	}{
+		{
+			desc:       "default namespace",
+			kubernetes: konjurev1beta2.Kubernetes{Types: []string{"deployments", "configmaps"}},
+			expected:   []string{"default/deployment/app", "default/configmap/config"},
+		},
+		{
+			desc:       "short names",
+			kubernetes: konjurev1beta2.Kubernetes{Namespace: "a", Types: []string{"deploy", "cm"}},
+			expected:   []string{"a/deployment/app-a", "a/configmap/config-a", "a/configmap/other-a"},
+		},
+		{
+			desc:       "kind and group",
+			kubernetes: konjurev1beta2.Kubernetes{Namespace: "a", Types: []string{"Deployment", "widgets.example.com"}},
+			expected:   []string{"a/deployment/app-a", "a/widget/widget-a"},
+		},
+		{
+			desc:       "namespace selector",
+			kubernetes: konjurev1beta2.Kubernetes{NamespaceSelector: "env=test", Types: []string{"cm"}},
+			expected:   []string{"a/configmap/config-a", "a/configmap/other-a", "b/configmap/config-b"},
+		},
+		{
+			desc:       "all namespaces",
+			kubernetes: konjurev1beta2.Kubernetes{AllNamespaces: true, Types: []string{"wd"}},
+			expected:   []string{"a/widget/widget-a", "b/widget/widget-b"},
+		},
+		{
+			desc:       "selector",
+			kubernetes: konjurev1beta2.Kubernetes{Namespaces: []string{"a", "b"}, Types: []string{"cm"}, Selector: "app=config"},
+			expected:   []string{"a/configmap/config-a", "b/configmap/config-b"},
+		},
+		{
+			desc:       "cluster scoped",
+			kubernetes: konjurev1beta2.Kubernetes{Namespaces: []string{"a", "b"}, Types: []string{"ns"}, Selector: "env=test"},
+			expected:   []string{"namespace/a", "namespace/b"},
+		},
+		{
+			desc:       "wildcard",
+			kubernetes: konjurev1beta2.Kubernetes{Namespace: "a", Types: []string{"*"}},
+			expected:   []string{"a/configmap/config-a", "a/configmap/other-a", "namespace/a", "namespace/b", "namespace/default", "a/deployment/app-a", "a/widget/widget-a"},
+		},
+		{
+			desc:       "wildcard exclusions",
+			kubernetes: konjurev1beta2.Kubernetes{Namespace: "a", Types: []string{"*"}, ExcludeTypes: []string{"namespaces", "configmaps", "widgets.example.com"}},
+			expected:   []string{"a/event/event-a", "a/deployment/app-a"},
+		},
+		{
+			desc:       "category",
+			kubernetes: konjurev1beta2.Kubernetes{Namespace: "a", Types: []string{"all", "deployments"}},
+			expected:   []string{"a/deployment/app-a", "a/widget/widget-a"},
+		},
+		{
+			desc:       "follow references",
+			kubernetes: konjurev1beta2.Kubernetes{Namespace: "c", Types: []string{"deployments"}, FollowReferences: 1},
+			expected:   []string{"c/deployment/web", "c/serviceaccount/web-sa", "c/configmap/web-config", "c/secret/web-secret", "c/replicaset/web-rs", "c/service/web"},
+		},
+		{
+			desc:       "follow references transitively",
+			kubernetes: konjurev1beta2.Kubernetes{Namespace: "c", Types: []string{"deployments"}, FollowReferences: 5},
+			expected:   []string{"c/deployment/web", "c/serviceaccount/web-sa", "c/configmap/web-config", "c/secret/web-secret", "c/replicaset/web-rs", "c/service/web", "c/pod/web-pod", "c/persistentvolumeclaim/web-data"},
+		},
+		{
+			desc:       "unknown type",
+			kubernetes: konjurev1beta2.Kubernetes{Types: []string{"gadgets"}},
+			err:        `the server doesn't have a resource type "gadgets"`,
+		},
+	}
+	for _, c := range cases {
+		t.Run(c.desc, func(t *testing.T) {
+			r := &KubernetesReader{
+				Kubernetes: c.kubernetes,
+				RESTConfig: &rest.Config{Host: srv.URL, QPS: -1},
+				ChunkSize:  1,
+			}
+
+			nodes, err := r.Read()
+			if c.err != "" {
+				assert.EqualError(t, err, c.err)
+				return
+			}
+			if assert.NoError(t, err) {
+				var actual []string
+				for _, n := range nodes {
+					actual = append(actual, resourceName(n))
+					assert.Nil(t, n.Field("metadata").Value.Field("managedFields"))
+				}
+				assert.Equal(t, c.expected, actual)
+			}
+		})
+	}
+}
+
+// newFakeAPIServer returns a handler which implements enough of the Kubernetes API for discovery and paged lists.
+func newFakeAPIServer(t *testing.T) http.Handler {
+	object := func(apiVersion, kind, namespace, name string, lbls map[string]string) map[string]interface{} {
+		md := map[string]interface{}{"name": name, "labels": lbls, "managedFields": []interface{}{map[string]interface{}{"manager": "test"}}}
+		if namespace != "" {
+			md["namespace"] = namespace
+		}
+		return map[string]interface{}{"apiVersion": apiVersion, "kind": kind, "metadata": md}
+	}
+
+	with := func(obj map[string]interface{}, value interface{}, path ...string) map[string]interface{} {
+		m := obj
+		for _, p := range path[:len(path)-1] {
+			if _, ok := m[p]; !ok {
+				m[p] = map[string]interface{}{}
+			}
+			m = m[p].(map[string]interface{})
+		}
+		m[path[len(path)-1]] = value
+		return obj
+	}
+	owner := func(apiVersion, kind, name string) []interface{} {
+		return []interface{}{map[string]interface{}{"apiVersion": apiVersion, "kind": kind, "name": name, "uid": name + "-uid"}}
+	}
+	podSpec := map[string]interface{}{
+		"serviceAccountName": "web-sa",
+		"volumes":            []interface{}{map[string]interface{}{"name": "config", "configMap": map[string]interface{}{"name": "web-config"}}},
+		"containers":         []interface{}{map[string]interface{}{"name": "web", "envFrom": []interface{}{map[string]interface{}{"secretRef": map[string]interface{}{"name": "web-secret"}}}}},
+	}
+	podTemplate := map[string]interface{}{"metadata": map[string]interface{}{"labels": map[string]interface{}{"app": "web"}}, "spec": podSpec}
+
+	objects := map[string][]map[string]interface{}{
+		"namespaces": {
+			object("v1", "Namespace", "", "a", map[string]string{"env": "test"}),
+			object("v1", "Namespace", "", "b", map[string]string{"env": "test"}),
+			object("v1", "Namespace", "", "default", nil),
+		},
+		"configmaps": {
+			object("v1", "ConfigMap", "a", "config-a", map[string]string{"app": "config"}),
+			object("v1", "ConfigMap", "a", "other-a", nil),
+			object("v1", "ConfigMap", "b", "config-b", map[string]string{"app": "config"}),
+			object("v1", "ConfigMap", "default", "config", nil),
+			object("v1", "ConfigMap", "c", "web-config", nil),
+		},
+		"secrets": {
+			object("v1", "Secret", "c", "web-secret", nil),
+		},
+		"serviceaccounts": {
+			object("v1", "ServiceAccount", "c", "web-sa", nil),
+		},
+		"persistentvolumeclaims": {
+			with(object("v1", "PersistentVolumeClaim", "c", "web-data", nil), "web-pv", "spec", "volumeName"),
+		},
+		"services": {
+			with(object("v1", "Service", "c", "web", nil), map[string]interface{}{"app": "web"}, "spec", "selector"),
+			with(object("v1", "Service", "c", "other", nil), map[string]interface{}{"app": "other"}, "spec", "selector"),
+		},
+		"pods": {
+			with(with(with(object("v1", "Pod", "c", "web-pod", map[string]string{"app": "web"}),
+				owner("apps/v1", "ReplicaSet", "web-rs"), "metadata", "ownerReferences"),
+				"web-pod-uid", "metadata", "uid"),
+				map[string]interface{}{"volumes": []interface{}{map[string]interface{}{"name": "data", "persistentVolumeClaim": map[string]interface{}{"claimName": "web-data"}}}}, "spec"),
+			object("v1", "Pod", "c", "unrelated", map[string]string{"app": "web"}),
+		},
+		"replicasets": {
+			with(with(with(object("apps/v1", "ReplicaSet", "c", "web-rs", nil),
+				owner("apps/v1", "Deployment", "web"), "metadata", "ownerReferences"),
+				"web-rs-uid", "metadata", "uid"),
+				podTemplate, "spec", "template"),
+		},
+		"events": {
+			object("v1", "Event", "a", "event-a", nil),
+		},
+		"deployments": {
+			object("apps/v1", "Deployment", "a", "app-a", nil),
+			object("apps/v1", "Deployment", "default", "app", nil),
+			with(with(object("apps/v1", "Deployment", "c", "web", nil), "web-uid", "metadata", "uid"), podTemplate, "spec", "template"),
+		},
+		"widgets": {
+			object("example.com/v1", "Widget", "a", "widget-a", nil),
+			object("example.com/v1", "Widget", "b", "widget-b", nil),
+		},
+	}
+
+	resources := map[string]*metav1.APIResourceList{
+		"v1": {GroupVersion: "v1", APIResources: []metav1.APIResource{
+			{Name: "namespaces", Kind: "Namespace", Verbs: []string{"list"}, ShortNames: []string{"ns"}},
+			{Name: "configmaps", Kind: "ConfigMap", Namespaced: true, Verbs: []string{"list"}, ShortNames: []string{"cm"}},
+			{Name: "events", Kind: "Event", Namespaced: true, Verbs: []string{"list"}, ShortNames: []string{"ev"}},
+			{Name: "pods", Kind: "Pod", Namespaced: true, Verbs: []string{"get", "list"}, ShortNames: []string{"po"}},
+			{Name: "pods/log", Kind: "Pod", Namespaced: true, Verbs: []string{"get"}},
+			{Name: "secrets", Kind: "Secret", Namespaced: true, Verbs: []string{"get", "list"}},
+			{Name: "serviceaccounts", Kind: "ServiceAccount", Namespaced: true, Verbs: []string{"get", "list"}, ShortNames: []string{"sa"}},
+			{Name: "services", Kind: "Service", Namespaced: true, Verbs: []string{"get", "list"}, ShortNames: []string{"svc"}},
+			{Name: "persistentvolumeclaims", Kind: "PersistentVolumeClaim", Namespaced: true, Verbs: []string{"get", "list"}, ShortNames: []string{"pvc"}},
+			{Name: "persistentvolumes", Kind: "PersistentVolume", Verbs: []string{"get", "list"}, ShortNames: []string{"pv"}},
+		}},
+		"apps/v1": {GroupVersion: "apps/v1", APIResources: []metav1.APIResource{
+			{Name: "deployments", Kind: "Deployment", Namespaced: true, Verbs: []string{"list"}, ShortNames: []string{"deploy"}, Categories: []string{"all"}},
+			{Name: "replicasets", Kind: "ReplicaSet", Namespaced: true, Verbs: []string{"get", "list"}, ShortNames: []string{"rs"}},
+		}},
+		"example.com/v1": {GroupVersion: "example.com/v1", APIResources: []metav1.APIResource{
+			{Name: "widgets", Kind: "Widget", Namespaced: true, Verbs: []string{"list"}, ShortNames: []string{"wd"}, Categories: []string{"all"}},
+		}},
+	}
+
+	groups := &metav1.APIGroupList{}
+	for _, gv := range []string{"apps/v1", "example.com/v1"} {
+		g, v, _ := strings.Cut(gv, "/")
+		gvd := metav1.GroupVersionForDiscovery{GroupVersion: gv, Version: v}
+		groups.Groups = append(groups.Groups, metav1.APIGroup{Name: g, Versions: []metav1.GroupVersionForDiscovery{gvd}, PreferredVersion: gvd})
+	}
+
+	write := func(w http.ResponseWriter, obj interface{}) {
+		w.Header().Set("Content-Type", "application/json")
+		if err := json.NewEncoder(w).Encode(obj); err != nil {
+			t.Error(err)
+		}
+	}
+
+	return http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		var gv string
+		var segments []string
+		switch path := strings.Trim(r.URL.Path, "/"); {
+		case path == "api":
+			write(w, &metav1.APIVersions{Versions: []string{"v1"}})
+			return
+		case path == "apis":
+			write(w, groups)
+			return
+		case strings.HasPrefix(path, "api/"):
+			segments = strings.Split(strings.TrimPrefix(path, "api/"), "/")
+			gv, segments = segments[0], segments[1:]
+		case strings.HasPrefix(path, "apis/"):
+			segments = strings.Split(strings.TrimPrefix(path, "apis/"), "/")
+			if len(segments) < 2 {
+				http.NotFound(w, r)
+				return
+			}
+			gv, segments = segments[0]+"/"+segments[1], segments[2:]
+		}
+
+		var namespace, resource, name string
+		switch len(segments) {
+		case 0:
+			if rl, ok := resources[gv]; ok {
+				write(w, rl)
+			} else {
+				http.NotFound(w, r)
+			}
+			return
+		case 1:
+			resource = segments[0]
+		case 2:
+			resource, name = segments[0], segments[1]
+		case 3:
+			namespace, resource = segments[1], segments[2]
+		case 4:
+			namespace, resource, name = segments[1], segments[2], segments[3]
+		default:
+			http.NotFound(w, r)
+			return
+		}
+
+		if name != "" {
+			for _, obj := range objects[resource] {
+				md := obj["metadata"].(map[string]interface{})
+				if md["name"] == name && (namespace == "" || md["namespace"] == namespace) {
+					write(w, obj)
+					return
+				}
+			}
+			w.Header().Set("Content-Type", "application/json")
+			w.WriteHeader(http.StatusNotFound)
+			write(w, &metav1.Status{TypeMeta: metav1.TypeMeta{APIVersion: "v1", Kind: "Status"}, Status: metav1.StatusFailure, Reason: metav1.StatusReasonNotFound, Code: http.StatusNotFound})
+			return
+		}
+
+		selector, err := labels.Parse(r.URL.Query().Get("labelSelector"))
+		if err != nil {
+			http.Error(w, err.Error(), http.StatusBadRequest)
+			return
+		}
+
+		var items []interface{}
+		for _, obj := range objects[resource] {
+			md := obj["metadata"].(map[string]interface{})
+			if namespace != "" && md["namespace"] != namespace {
+				continue
+			}
+			if !selector.Matches(labels.Set(md["labels"].(map[string]string))) {
+				continue
+			}
+			items = append(items, obj)
+		}
+
+		// Page the results using the offset as the continue token
+		list := map[string]interface{}{"apiVersion": "v1", "kind": "List", "metadata": map[string]interface{}{}}
+		start, _ := strconv.Atoi(r.URL.Query().Get("continue"))
+		end := len(items)
+		if limit, _ := strconv.Atoi(r.URL.Query().Get("limit")); limit > 0 && start+limit < end {
+			end = start + limit
+			list["metadata"] = map[string]interface{}{"continue": strconv.Itoa(end)}
+		}
+		list["items"] = items[start:end]
+		write(w, list)
+	})
+}
@@ -0,0 +1,307 @@
+/*
+Copyright 2023 GramLabs, Inc.
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package readers
+
+import (
+	"context"
+
+	apierrors "k8s.io/apimachinery/pkg/api/errors"
+	"k8s.io/apimachinery/pkg/api/meta"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	"k8s.io/apimachinery/pkg/apis/meta/v1/unstructured"
+	"k8s.io/apimachinery/pkg/labels"
+	"k8s.io/apimachinery/pkg/runtime/schema"
+	"k8s.io/client-go/dynamic"
+)
+
+// ownedTypes are the types which may be owned by each of the built-in controllers.
+var ownedTypes = map[schema.GroupKind][]schema.GroupKind{
+	{Group: "apps", Kind: "Deployment"}:  {{Group: "apps", Kind: "ReplicaSet"}},
+	{Group: "apps", Kind: "ReplicaSet"}:  {{Kind: "Pod"}},
+	{Group: "apps", Kind: "StatefulSet"}: {{Kind: "Pod"}},
+	{Group: "apps", Kind: "DaemonSet"}:   {{Kind: "Pod"}},
+	{Group: "batch", Kind: "Job"}:        {{Kind: "Pod"}},
+	{Group: "batch", Kind: "CronJob"}:    {{Group: "batch", Kind: "Job"}},
+}
+
+// objectKey uniquely identifies an object.
+type objectKey struct {
+	schema.GroupKind
+	Namespace string
+	Name      string
+}
+
+// objectRef is a reference to an object in the same namespace as the referencing object.
+type objectRef struct {
+	schema.GroupVersionKind
+	Name string
+}
+
+// referenceFollower finds the objects related to objects read from a cluster.
+type referenceFollower struct {
+	client dynamic.Interface
+	mapper meta.RESTMapper
+	list   func(context.Context, dynamic.ResourceInterface, metav1.ListOptions) ([]*unstructured.Unstructured, error)
+
+	lists map[schema.GroupVersionResource]map[string][]*unstructured.Unstructured
+}
+
+// follow returns the objects transitively related to the supplied objects, up to the specified depth.
+func (rf *referenceFollower) follow(ctx context.Context, objs []*unstructured.Unstructured, depth int) ([]*unstructured.Unstructured, error) {
+	seen := make(map[objectKey]struct{}, len(objs))
+	for _, obj := range objs {
+		seen[keyOf(obj)] = struct{}{}
+	}
+
+	var result []*unstructured.Unstructured
+	for i := 0; i < depth && len(objs) > 0; i++ {
+		var next []*unstructured.Unstructured
+		for _, obj := range objs {
+			related, err := rf.related(ctx, obj)
+			if err != nil {
+				return nil, err
+			}
+
+			for _, r := range related {
+				if _, ok := seen[keyOf(r)]; ok {
+					continue
+				}
+				seen[keyOf(r)] = struct{}{}
+				next = append(next, r)
+			}
+		}
+
+		result = append(result, next...)
+		objs = next
+	}
+
+	return result, nil
+}
+
+// related returns the owners, owned children, referenced objects and selecting services of an object.
+func (rf *referenceFollower) related(ctx context.Context, obj *unstructured.Unstructured) ([]*unstructured.Unstructured, error) {
+	ns := obj.GetNamespace()
+
+	var refs []objectRef
+	for _, owner := range obj.GetOwnerReferences() {
+		refs = append(refs, objectRef{GroupVersionKind: schema.FromAPIVersionAndKind(owner.APIVersion, owner.Kind), Name: owner.Name})
+	}
+	refs = append(refs, podSpecRefs(obj)...)
+	if obj.GetAPIVersion() == "v1" && obj.GetKind() == "PersistentVolumeClaim" {
+		if name, _, _ := unstructured.NestedString(obj.Object, "spec", "volumeName"); name != "" {
+			refs = append(refs, objectRef{GroupVersionKind: schema.GroupVersionKind{Version: "v1", Kind: "PersistentVolume"}, Name: name})
+		}
+	}
+
+	var result []*unstructured.Unstructured
+	for _, ref := range refs {
+		r, err := rf.get(ctx, ref, ns)
+		if err != nil {
+			return nil, err
+		}
+		if r != nil {
+			result = append(result, r)
+		}
+	}
+
+	// Owned children must be found by listing the types the object may own
+	for _, gk := range ownedTypes[obj.GroupVersionKind().GroupKind()] {
+		items, err := rf.namespaceList(ctx, gk.WithVersion(""), ns)
+		if err != nil {
+			return nil, err
+		}
+		for _, item := range items {
+			for _, owner := range item.GetOwnerReferences() {
+				if owner.UID == obj.GetUID() {
+					result = append(result, item)
+					break
+				}
+			}
+		}
+	}
+
+	// Services must be found by listing the services and matching their selectors
+	if podLabels := podTemplateLabels(obj); len(podLabels) > 0 {
+		services, err := rf.namespaceList(ctx, schema.GroupVersionKind{Version: "v1", Kind: "Service"}, ns)
+		if err != nil {
+			return nil, err
+		}
+		for _, svc := range services {
+			selector, _, _ := unstructured.NestedStringMap(svc.Object, "spec", "selector")
+			if len(selector) > 0 && labels.SelectorFromSet(selector).Matches(labels.Set(podLabels)) {
+				result = append(result, svc)
+			}
+		}
+	}
+
+	return result, nil
+}
+
+// get returns the referenced object, or nil if it does not exist or cannot be accessed.
+func (rf *referenceFollower) get(ctx context.Context, ref objectRef, ns string) (*unstructured.Unstructured, error) {
+	m, err := rf.mapper.RESTMapping(ref.GroupKind(), ref.Version)
+	if err != nil {
+		if meta.IsNoMatchError(err) {
+			return nil, nil
+		}
+		return nil, err
+	}
+
+	var ri dynamic.ResourceInterface = rf.client.Resource(m.Resource)
+	if m.Scope.Name() == meta.RESTScopeNameNamespace {
+		ri = rf.client.Resource(m.Resource).Namespace(ns)
+	}
+
+	obj, err := ri.Get(ctx, ref.Name, metav1.GetOptions{})
+	if apierrors.IsNotFound(err) || apierrors.IsForbidden(err) {
+		return nil, nil
+	}
+	return obj, err
+}
+
+// namespaceList returns all the objects of the specified type in a namespace, the results are cached.
+func (rf *referenceFollower) namespaceList(ctx context.Context, gvk schema.GroupVersionKind, ns string) ([]*unstructured.Unstructured, error) {
+	m, err := rf.mapper.RESTMapping(gvk.GroupKind(), gvk.Version)
+	if err != nil {
+		if meta.IsNoMatchError(err) {
+			return nil, nil
+		}
+		return nil, err
+	}
+
+	if items, ok := rf.lists[m.Resource][ns]; ok {
+		return items, nil
+	}
+
+	items, err := rf.list(ctx, rf.client.Resource(m.Resource).Namespace(ns), metav1.ListOptions{})
+	if apierrors.IsForbidden(err) {
+		items, err = nil, nil
+	}
+	if err != nil {
+		return nil, err
+	}
+
+	if rf.lists == nil {
+		rf.lists = make(map[schema.GroupVersionResource]map[string][]*unstructured.Unstructured)
+	}
+	if rf.lists[m.Resource] == nil {
+		rf.lists[m.Resource] = make(map[string][]*unstructured.Unstructured)
+	}
+	rf.lists[m.Resource][ns] = items
+	return items, nil
+}
+
+// keyOf returns the key of an object.
+func keyOf(obj *unstructured.Unstructured) objectKey {
+	return objectKey{GroupKind: obj.GroupVersionKind().GroupKind(), Namespace: obj.GetNamespace(), Name: obj.GetName()}
+}
+
+// podTemplatePath returns the path to the pod template of an object, the path
+// to a pod itself is empty. If the object has no pod template, the result is nil.
+func podTemplatePath(obj *unstructured.Unstructured) []string {
+	switch obj.GroupVersionKind().GroupKind() {
+	case schema.GroupKind{Kind: "Pod"}:
+		return []string{}
+	case schema.GroupKind{Group: "apps", Kind: "Deployment"},
+		schema.GroupKind{Group: "apps", Kind: "ReplicaSet"},
+		schema.GroupKind{Group: "apps", Kind: "StatefulSet"},
+		schema.GroupKind{Group: "apps", Kind: "DaemonSet"},
+		schema.GroupKind{Group: "batch", Kind: "Job"},
+		schema.GroupKind{Kind: "ReplicationController"}:
+		return []string{"spec", "template"}
+	case schema.GroupKind{Group: "batch", Kind: "CronJob"}:
+		return []string{"spec", "jobTemplate", "spec", "template"}
+	}
+	return nil
+}
+
+// podTemplateLabels returns the labels of the pod (or pod template) of an object.
+func podTemplateLabels(obj *unstructured.Unstructured) map[string]string {
+	path := podTemplatePath(obj)
+	if path == nil {
+		return nil
+	}
+	result, _, _ := unstructured.NestedStringMap(obj.Object, append(path, "metadata", "labels")...)
+	return result
+}
+
+// podSpecRefs returns the ConfigMaps, Secrets, PersistentVolumeClaims and ServiceAccounts referenced
+// from the pod (or pod template) of an object.
+func podSpecRefs(obj *unstructured.Unstructured) []objectRef {
+	path := podTemplatePath(obj)
+	if path == nil {
+		return nil
+	}
+	spec, _, _ := unstructured.NestedMap(obj.Object, append(path, "spec")...)
+	if spec == nil {
+		return nil
+	}
+
+	var refs []objectRef
+	add := func(kind string, name string) {
+		if name != "" {
+			refs = append(refs, objectRef{GroupVersionKind: schema.GroupVersionKind{Version: "v1", Kind: kind}, Name: name})
+		}
+	}
+	str := func(obj map[string]interface{}, fields ...string) string {
+		s, _, _ := unstructured.NestedString(obj, fields...)
+		return s
+	}
+	each := func(obj map[string]interface{}, fields []string, fn func(map[string]interface{})) {
+		items, _, _ := unstructured.NestedSlice(obj, fields...)
+		for _, item := range items {
+			if m, ok := item.(map[string]interface{}); ok {
+				fn(m)
+			}
+		}
+	}
+
+	if sa := str(spec, "serviceAccountName"); sa != "" {
+		add("ServiceAccount", sa)
+	} else {
+		add("ServiceAccount", str(spec, "serviceAccount"))
+	}
+
+	each(spec, []string{"imagePullSecrets"}, func(s map[string]interface{}) {
+		add("Secret", str(s, "name"))
+	})
+
+	each(spec, []string{"volumes"}, func(v map[string]interface{}) {
+		add("ConfigMap", str(v, "configMap", "name"))
+		add("Secret", str(v, "secret", "secretName"))
+		add("PersistentVolumeClaim", str(v, "persistentVolumeClaim", "claimName"))
+		each(v, []string{"projected", "sources"}, func(s map[string]interface{}) {
+			add("ConfigMap", str(s, "configMap", "name"))
+			add("Secret", str(s, "secret", "name"))
+		})
+	})
+
+	for _, containers := range []string{"initContainers", "containers"} {
+		each(spec, []string{containers}, func(c map[string]interface{}) {
+			each(c, []string{"envFrom"}, func(e map[string]interface{}) {
+				add("ConfigMap", str(e, "configMapRef", "name"))
+				add("Secret", str(e, "secretRef", "name"))
+			})
+			each(c, []string{"env"}, func(e map[string]interface{}) {
+				add("ConfigMap", str(e, "valueFrom", "configMapKeyRef", "name"))
+				add("Secret", str(e, "valueFrom", "secretKeyRef", "name"))
+			})
+		})
+	}
+
+	return refs
+}
@@ -17,22 +17,154 @@ limitations under the License.
 package readers
 
 import (
+	"context"
+	"fmt"
+	"os"
+	"path/filepath"
+
 	konjurev1beta2 "github.com/thestormforge/konjure/pkg/api/core/v1beta2"
+	"sigs.k8s.io/kustomize/api/konfig"
+	"sigs.k8s.io/kustomize/api/krusty"
+	"sigs.k8s.io/kustomize/kyaml/filesys"
 	"sigs.k8s.io/kustomize/kyaml/yaml"
 )
 
+// KustomizeReader builds a kustomization in-process. Remote roots, bases and
+// resources are fetched by Kustomize itself and do not use the Konjure cache
+// or lock; when a lock is configured, kustomizations referencing remote
+// content are rejected (use a `Git` resource to pin remote kustomizations).
 type KustomizeReader struct {
 	konjurev1beta2.Kustomize
 	Runtime
+
+	// The file system used to build the kustomization, defaults to the local file system.
+	FileSystem filesys.FileSystem
+	// Function used to determine an absolute path.
+	Abs func(path string) (string, error)
+	// Optional tracker used to record the files read from the local file system.
+	Tracker *Tracker
+	// The lock used to pin remote resources, Kustomize cannot honor it.
+	Lock *Lock
+
+	ctx context.Context
 }
 
 func (kustomize *KustomizeReader) Read() ([]*yaml.RNode, error) {
-	cmd := kustomize.command()
-	cmd.Args = append(cmd.Args, "build", kustomize.Root)
-	return cmd.Read()
+	root, err := kustomize.root()
+	if err != nil {
+		return nil, err
+	}
+
+	ctx, cancel, err := readContext(kustomize.ctx, kustomize.Timeout)
+	if err != nil {
+		return nil, err
+	}
+	defer cancel()
+
+	// Only use the kustomize binary if it was explicitly requested
+	if kustomize.Bin != "" || kustomize.Executor != nil {
+		cmd := kustomize.command(ctx)
+		cmd.Args = append(cmd.Args, "build", root)
+		return cmd.Read()
+	}
+
+	fs := kustomize.FileSystem
+	if fs == nil {
+		fs = filesys.MakeFsOnDisk()
+	}
+	if kustomize.Lock != nil {
+		if err := checkLocalKustomization(fs, root); err != nil {
+			return nil, err
+		}
+	}
+	if kustomize.Tracker != nil {
+		fs = &trackingFileSystem{FileSystem: fs, tracker: kustomize.Tracker}
+	}
+
+	return readAsync(ctx, func() ([]*yaml.RNode, error) {
+		m, err := krusty.MakeKustomizer(krusty.MakeDefaultOptions()).Run(fs, root)
+		if err != nil {
+			return nil, fmt.Errorf("kustomize build %s: %w", root, err)
+		}
+		return m.ToRNodeSlice(), nil
+	})
 }
 
-func (kustomize *KustomizeReader) command() *command {
-	cmd := kustomize.Runtime.command("kustomize")
+func (kustomize *KustomizeReader) command(ctx context.Context) *command {
+	cmd := kustomize.Runtime.command(ctx, "kustomize")
 	return cmd
 }
+
+// root returns the kustomization root, resolving relative paths if possible.
+func (kustomize *KustomizeReader) root() (string, error) {
+	if kustomize.Abs == nil || filepath.IsAbs(kustomize.Root) {
+		return kustomize.Root, nil
+	}
+
+	// The root may also be a remote URL, only use the absolute path if it exists
+	path, err := kustomize.Abs(kustomize.Root)
+	if err != nil {
+		return "", err
+	}
+	if _, err := os.Stat(path); err != nil {
+		return kustomize.Root, nil
+	}
+	return path, nil
+}
+
+// checkLocalKustomization returns an error if the kustomization rooted at the
+// supplied directory references anything that is not on the file system (which
+// Kustomize would treat as a remote URL).
+func checkLocalKustomization(fs filesys.FileSystem, dir string) error {
+	if !fs.Exists(dir) {
+		return fmt.Errorf("remote kustomization %s cannot be locked", dir)
+	}
+
+	for _, name := range konfig.RecognizedKustomizationFileNames() {
+		data, err := fs.ReadFile(filepath.Join(dir, name))
+		if err != nil {
+			continue
+		}
+
+		k := struct {
+			Resources  []string `yaml:"resources"`
+			Bases      []string `yaml:"bases"`
+			Components []string `yaml:"components"`
+		}{}
+		if err := yaml.Unmarshal(data, &k); err != nil {
+			return fmt.Errorf("invalid kustomization %s: %w", filepath.Join(dir, name), err)
+		}
+
+		for _, ref := range append(append(k.Resources, k.Bases...), k.Components...) {
+			path := ref
+			if !filepath.IsAbs(path) {
+				path = filepath.Join(dir, ref)
+			}
+			if !fs.Exists(path) {
+				return fmt.Errorf("remote kustomization resource %s in %s cannot be locked", ref, dir)
+			}
+			if fs.IsDir(path) {
+				if err := checkLocalKustomization(fs, path); err != nil {
+					return err
+				}
+			}
+		}
+		return nil
+	}
+
+	return nil
+}
+
+// trackingFileSystem records the files Kustomize reads.
+type trackingFileSystem struct {
+	filesys.FileSystem
+	tracker *Tracker
+}
+
+func (fs *trackingFileSystem) ReadFile(path string) ([]byte, error) {
+	data, err := fs.FileSystem.ReadFile(path)
+	if err == nil {
+		fs.tracker.Track(path)
+	}
+	return data, err
+}
@@ -0,0 +1,79 @@
+/*
+Copyright 2023 GramLabs, Inc.
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package readers
+
+import (
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+	konjurev1beta2 "github.com/thestormforge/konjure/pkg/api/core/v1beta2"
+	"sigs.k8s.io/kustomize/kyaml/filesys"
+)
+
+func TestKustomizeReader_Read(t *testing.T) {
+	fs := filesys.MakeFsInMemory()
+	require.NoError(t, fs.WriteFile("/app/kustomization.yaml", []byte(`namePrefix: test-
+resources:
+- configmap.yaml
+`)))
+	require.NoError(t, fs.WriteFile("/app/configmap.yaml", []byte(`apiVersion: v1
+kind: ConfigMap
+metadata:
+  name: example
+`)))
+
+	r := &KustomizeReader{
+		Kustomize:  konjurev1beta2.Kustomize{Root: "/app"},
+		FileSystem: fs,
+	}
+
+	nodes, err := r.Read()
+	if assert.NoError(t, err) && assert.Len(t, nodes, 1) {
+		assert.Equal(t, "test-example", nodes[0].GetName())
+	}
+}
+
+func TestKustomizeReader_Read_lock(t *testing.T) {
+	fs := filesys.MakeFsInMemory()
+	require.NoError(t, fs.WriteFile("/app/kustomization.yaml", []byte(`resources:
+- ../base
+`)))
+	require.NoError(t, fs.WriteFile("/base/kustomization.yaml", []byte(`resources:
+- configmap.yaml
+- https://github.com/example/repo//config?ref=main
+`)))
+	require.NoError(t, fs.WriteFile("/base/configmap.yaml", []byte(`apiVersion: v1
+kind: ConfigMap
+metadata:
+  name: example
+`)))
+
+	r := &KustomizeReader{
+		Kustomize:  konjurev1beta2.Kustomize{Root: "/app"},
+		FileSystem: fs,
+		Lock:       &Lock{Update: true},
+	}
+
+	_, err := r.Read()
+	assert.EqualError(t, err, "remote kustomization resource https://github.com/example/repo//config?ref=main in /base cannot be locked")
+
+	// Remote roots cannot be locked either
+	r.Root = "https://github.com/example/repo//config?ref=main"
+	_, err = r.Read()
+	assert.EqualError(t, err, "remote kustomization https://github.com/example/repo//config?ref=main cannot be locked")
+}
@@ -0,0 +1,230 @@
+/*
+Copyright 2023 GramLabs, Inc.
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package readers
+
+import (
+	"fmt"
+	"os"
+	"sort"
+	"sync"
+
+	"sigs.k8s.io/kustomize/kyaml/yaml"
+)
+
+// LockFile is the default name of the lock file.
+const LockFile = "konjure.lock"
+
+// LockedGit is the immutable identity of a Git repository.
+type LockedGit struct {
+	// The Git repository URL.
+	Repository string `json:"repo" yaml:"repo"`
+	// The requested refspec.
+	Refspec string `json:"refspec,omitempty" yaml:"refspec,omitempty"`
+	// The resolved commit SHA.
+	Commit string `json:"commit" yaml:"commit"`
+}
+
+// LockedHelm is the immutable identity of a Helm chart.
+type LockedHelm struct {
+	// The repository URL the chart is fetched from.
+	Repository string `json:"repo" yaml:"repo"`
+	// The chart name.
+	Chart string `json:"chart" yaml:"chart"`
+	// The requested version (if any).
+	Constraint string `json:"constraint,omitempty" yaml:"constraint,omitempty"`
+	// The resolved version of the chart.
+	Version string `json:"version" yaml:"version"`
+	// The digest of the chart archive, if available.
+	Digest string `json:"digest,omitempty" yaml:"digest,omitempty"`
+}
+
+// LockedHTTP is the immutable identity of an HTTP resource.
+type LockedHTTP struct {
+	// The HTTP(S) URL.
+	URL string `json:"url" yaml:"url"`
+	// The digest of the content.
+	Digest string `json:"digest" yaml:"digest"`
+}
+
+// Lock records the immutable identities of remote resources so expansions can
+// be reproduced. When updating, resolved identities are recorded; otherwise they
+// are verified and any unlocked or drifted resource is an error.
+type Lock struct {
+	Git  []LockedGit  `json:"git,omitempty" yaml:"git,omitempty"`
+	Helm []LockedHelm `json:"helm,omitempty" yaml:"helm,omitempty"`
+	HTTP []LockedHTTP `json:"http,omitempty" yaml:"http,omitempty"`
+
+	// Flag indicating resolved identities should be recorded instead of verified.
+	Update bool `json:"-" yaml:"-"`
+
+	mu sync.Mutex
+}
+
+// ReadLockFile reads a lock from the named file.
+func ReadLockFile(name string) (*Lock, error) {
+	data, err := os.ReadFile(name)
+	if err != nil {
+		return nil, err
+	}
+
+	l := &Lock{}
+	if err := yaml.Unmarshal(data, l); err != nil {
+		return nil, fmt.Errorf("invalid lock file %s: %w", name, err)
+	}
+	return l, nil
+}
+
+// WriteFile writes the lock to the named file.
+func (l *Lock) WriteFile(name string) error {
+	l.mu.Lock()
+	defer l.mu.Unlock()
+
+	// Sort everything so the file is stable regardless of expansion order
+	sort.Slice(l.Git, func(i, j int) bool {
+		if l.Git[i].Repository != l.Git[j].Repository {
+			return l.Git[i].Repository < l.Git[j].Repository
+		}
+		return l.Git[i].Refspec < l.Git[j].Refspec
+	})
+	sort.Slice(l.Helm, func(i, j int) bool {
+		if l.Helm[i].Repository != l.Helm[j].Repository {
+			return l.Helm[i].Repository < l.Helm[j].Repository
+		}
+		if l.Helm[i].Chart != l.Helm[j].Chart {
+			return l.Helm[i].Chart < l.Helm[j].Chart
+		}
+		return l.Helm[i].Constraint < l.Helm[j].Constraint
+	})
+	sort.Slice(l.HTTP, func(i, j int) bool {
+		return l.HTTP[i].URL < l.HTTP[j].URL
+	})
+
+	data, err := yaml.Marshal(l)
+	if err != nil {
+		return err
+	}
+
+	return os.WriteFile(name, data, 0644)
+}
+
+// git returns the locked commit for a repository and refspec. The result is
+// empty if there is no lock or the lock is being updated.
+func (l *Lock) git(repo, refspec string) (string, error) {
+	if l == nil || l.Update {
+		return "", nil
+	}
+
+	l.mu.Lock()
+	defer l.mu.Unlock()
+	for _, g := range l.Git {
+		if g.Repository == repo && g.Refspec == refspec {
+			return g.Commit, nil
+		}
+	}
+	return "", fmt.Errorf("git repository %s (%s) is not locked", repo, refspec)
+}
+
+// verifyGit records or verifies the commit for a repository and refspec.
+func (l *Lock) verifyGit(repo, refspec, commit string) error {
+	if l == nil {
+		return nil
+	}
+
+	l.mu.Lock()
+	defer l.mu.Unlock()
+	for _, g := range l.Git {
+		if g.Repository == repo && g.Refspec == refspec {
+			if g.Commit != commit {
+				return fmt.Errorf("git repository %s (%s) drifted from locked commit %s to %s", repo, refspec, g.Commit, commit)
+			}
+			return nil
+		}
+	}
+
+	if !l.Update {
+		return fmt.Errorf("git repository %s (%s) is not locked", repo, refspec)
+	}
+	l.Git = append(l.Git, LockedGit{Repository: repo, Refspec: refspec, Commit: commit})
+	return nil
+}
+
+// helm returns the locked chart for a repository, chart and version constraint.
+// The result is nil if there is no lock or the lock is being updated.
+func (l *Lock) helm(repo, chart, constraint string) (*LockedHelm, error) {
+	if l == nil || l.Update {
+		return nil, nil
+	}
+
+	l.mu.Lock()
+	defer l.mu.Unlock()
+	for i := range l.Helm {
+		if l.Helm[i].Repository == repo && l.Helm[i].Chart == chart && l.Helm[i].Constraint == constraint {
+			h := l.Helm[i]
+			return &h, nil
+		}
+	}
+	return nil, fmt.Errorf("helm chart %s from %s (%s) is not locked", chart, repo, constraint)
+}
+
+// verifyHelm records or verifies the resolved chart.
+func (l *Lock) verifyHelm(locked LockedHelm) error {
+	if l == nil {
+		return nil
+	}
+
+	l.mu.Lock()
+	defer l.mu.Unlock()
+	for _, h := range l.Helm {
+		if h.Repository == locked.Repository && h.Chart == locked.Chart && h.Constraint == locked.Constraint {
+			if h.Version != locked.Version || h.Digest != locked.Digest {
+				return fmt.Errorf("helm chart %s from %s drifted from locked version %s (%s) to %s (%s)",
+					locked.Chart, locked.Repository, h.Version, h.Digest, locked.Version, locked.Digest)
+			}
+			return nil
+		}
+	}
+
+	if !l.Update {
+		return fmt.Errorf("helm chart %s from %s (%s) is not locked", locked.Chart, locked.Repository, locked.Constraint)
+	}
+	l.Helm = append(l.Helm, locked)
+	return nil
+}
+
+// verifyHTTP records or verifies the content digest for a URL.
+func (l *Lock) verifyHTTP(url, digest string) error {
+	if l == nil {
+		return nil
+	}
+
+	l.mu.Lock()
+	defer l.mu.Unlock()
+	for _, h := range l.HTTP {
+		if h.URL == url {
+			if h.Digest != digest {
+				return fmt.Errorf("content of %s drifted from locked digest %s to %s", url, h.Digest, digest)
+			}
+			return nil
+		}
+	}
+
+	if !l.Update {
+		return fmt.Errorf("HTTP resource %s is not locked", url)
+	}
+	l.HTTP = append(l.HTTP, LockedHTTP{URL: url, Digest: digest})
+	return nil
+}
@@ -0,0 +1,60 @@
+/*
+Copyright 2023 GramLabs, Inc.
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package readers
+
+import (
+	"net/http"
+	"net/http/httptest"
+	"path/filepath"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+	konjurev1beta2 "github.com/thestormforge/konjure/pkg/api/core/v1beta2"
+)
+
+func TestHTTPReader_Lock(t *testing.T) {
+	content := "apiVersion: v1\nkind: ConfigMap\nmetadata:\n  name: test\n"
+	srv := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		_, _ = w.Write([]byte(content))
+	}))
+	defer srv.Close()
+
+	// Record the content digest and round trip it through a file
+	lock := &Lock{Update: true}
+	_, err := (&HTTPReader{HTTP: konjurev1beta2.HTTP{URL: srv.URL}, Lock: lock}).Read()
+	require.NoError(t, err)
+	require.Len(t, lock.HTTP, 1)
+
+	lockFile := filepath.Join(t.TempDir(), LockFile)
+	require.NoError(t, lock.WriteFile(lockFile))
+	lock, err = ReadLockFile(lockFile)
+	require.NoError(t, err)
+
+	// Verify the unchanged content
+	_, err = (&HTTPReader{HTTP: konjurev1beta2.HTTP{URL: srv.URL}, Lock: lock}).Read()
+	assert.NoError(t, err)
+
+	// Unlocked resources are not allowed
+	_, err = (&HTTPReader{HTTP: konjurev1beta2.HTTP{URL: srv.URL + "/other"}, Lock: lock}).Read()
+	assert.ErrorContains(t, err, "is not locked")
+
+	// Changing the content is drift
+	content += "data:\n  foo: bar\n"
+	_, err = (&HTTPReader{HTTP: konjurev1beta2.HTTP{URL: srv.URL}, Lock: lock}).Read()
+	assert.ErrorContains(t, err, "drifted")
+}
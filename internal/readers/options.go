@@ -17,9 +17,12 @@ limitations under the License.
 package readers
 
 import (
+	"context"
 	"io"
 	"path/filepath"
 
+	"github.com/google/go-jsonnet"
+	konjurev1beta2 "github.com/thestormforge/konjure/pkg/api/core/v1beta2"
 	"sigs.k8s.io/kustomize/kyaml/kio"
 )
 
@@ -46,8 +49,11 @@ func WithWorkingDirectory(dir string) Option {
 	}
 
 	return func(r kio.Reader) kio.Reader {
-		if fr, ok := r.(*FileReader); ok {
-			fr.Abs = abs
+		switch rr := r.(type) {
+		case *FileReader:
+			rr.Abs = abs
+		case *KustomizeReader:
+			rr.Abs = abs
 		}
 		return r
 	}
@@ -73,11 +79,55 @@ func WithKubeconfig(kubeconfig string) Option {
 	}
 }
 
-// WithKubectlExecutor controls the alternate executor for kubectl.
-func WithKubectlExecutor(executor Executor) Option {
+// WithHelmExecutor controls the alternate executor for helm.
+func WithHelmExecutor(executor Executor) Option {
 	return func(r kio.Reader) kio.Reader {
-		if kr, ok := r.(*KubernetesReader); ok {
-			kr.Executor = executor
+		if hr, ok := r.(*HelmReader); ok {
+			hr.Executor = executor
+		}
+		return r
+	}
+}
+
+// WithHelmRelease sets the release name and namespace used when they are not specified.
+func WithHelmRelease(name, namespace string) Option {
+	return func(r kio.Reader) kio.Reader {
+		if hr, ok := r.(*HelmReader); ok {
+			if hr.ReleaseName == "" {
+				hr.ReleaseName = name
+			}
+			if hr.ReleaseNamespace == "" {
+				hr.ReleaseNamespace = namespace
+			}
+		}
+		return r
+	}
+}
+
+// WithHelmValues adds values which take precedence over the values of each chart.
+func WithHelmValues(values ...konjurev1beta2.HelmValue) Option {
+	return func(r kio.Reader) kio.Reader {
+		if hr, ok := r.(*HelmReader); ok && len(values) > 0 {
+			hr.Values = append(hr.Values[:len(hr.Values):len(hr.Values)], values...)
+		}
+		return r
+	}
+}
+
+// WithJsonnetExternalVariables adds external variables which take precedence over
+// the external variables of each program.
+func WithJsonnetExternalVariables(extVars ...konjurev1beta2.JsonnetParameter) Option {
+	return func(r kio.Reader) kio.Reader {
+		if jr, ok := r.(*JsonnetReader); ok && len(extVars) > 0 {
+			makeVM := jr.MakeVM
+			if makeVM == nil {
+				makeVM = jsonnet.MakeVM
+			}
+			jr.MakeVM = func() *jsonnet.VM {
+				vm := makeVM()
+				processParameters(extVars, vm.ExtVar, vm.ExtCode)
+				return vm
+			}
 		}
 		return r
 	}
@@ -102,3 +152,98 @@ func WithDefaultTypes(types ...string) Option {
 		return r
 	}
 }
+
+// WithExport controls the removal of server populated fields from Kubernetes resources.
+func WithExport(export, removeDefaults bool) Option {
+	return func(r kio.Reader) kio.Reader {
+		if kr, ok := r.(*KubernetesReader); ok {
+			kr.Export = kr.Export || export
+			kr.RemoveDefaults = kr.RemoveDefaults || removeDefaults
+		}
+		return r
+	}
+}
+
+// WithContext configures the context used to cancel readers.
+func WithContext(ctx context.Context) Option {
+	return func(r kio.Reader) kio.Reader {
+		switch rr := r.(type) {
+		case *GitReader:
+			rr.ctx = ctx
+		case *HelmReader:
+			rr.ctx = ctx
+		case *HTTPReader:
+			rr.ctx = ctx
+		case *JsonnetReader:
+			rr.ctx = ctx
+		case *KubernetesReader:
+			rr.ctx = ctx
+		case *KustomizeReader:
+			rr.ctx = ctx
+		}
+		return r
+	}
+}
+
+// WithGitToken configures the token used to authenticate to HTTP(S) Git repositories.
+func WithGitToken(token string) Option {
+	return func(r kio.Reader) kio.Reader {
+		if gr, ok := r.(*GitReader); ok {
+			gr.Token = token
+		}
+		return r
+	}
+}
+
+// WithTracker configures the tracker used to record the local files read.
+func WithTracker(tracker *Tracker) Option {
+	return func(r kio.Reader) kio.Reader {
+		switch rr := r.(type) {
+		case *FileReader:
+			rr.Tracker = tracker
+		case *HelmReader:
+			rr.Tracker = tracker
+		case *JsonnetReader:
+			rr.Tracker = tracker
+		case *KustomizeReader:
+			rr.Tracker = tracker
+		case *SecretReader:
+			rr.Tracker = tracker
+		case *ConfigMapReader:
+			rr.Tracker = tracker
+		}
+		return r
+	}
+}
+
+// WithCache configures the cache used by readers of remote resources.
+func WithCache(cache *Cache) Option {
+	return func(r kio.Reader) kio.Reader {
+		switch rr := r.(type) {
+		case *GitReader:
+			rr.Cache = cache
+		case *HelmReader:
+			rr.Cache = cache
+		case *HTTPReader:
+			rr.Cache = cache
+		}
+		return r
+	}
+}
+
+// WithLock configures the lock used to pin and verify remote resources.
+func WithLock(lock *Lock) Option {
+	return func(r kio.Reader) kio.Reader {
+		switch rr := r.(type) {
+		case *GitReader:
+			rr.Lock = lock
+		case *HelmReader:
+			rr.Lock = lock
+		case *HTTPReader:
+			rr.Lock = lock
+		case *KustomizeReader:
+			rr.Lock = lock
+		}
+		return r
+	}
+}
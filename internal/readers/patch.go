@@ -0,0 +1,167 @@
+/*
+Copyright 2023 GramLabs, Inc.
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package readers
+
+import (
+	"fmt"
+	"regexp"
+
+	konjurev1beta2 "github.com/thestormforge/konjure/pkg/api/core/v1beta2"
+	"github.com/thestormforge/konjure/pkg/filters"
+	"sigs.k8s.io/kustomize/api/filters/patchjson6902"
+	"sigs.k8s.io/kustomize/api/filters/patchstrategicmerge"
+	"sigs.k8s.io/kustomize/kyaml/kio"
+	"sigs.k8s.io/kustomize/kyaml/yaml"
+)
+
+// PatchReader expands the configured sources and applies the patches to the result.
+type PatchReader struct {
+	konjurev1beta2.Patch
+
+	// The filter used to expand the sources, defaults to the full depth.
+	Expander *Filter
+}
+
+// Read expands the sources and returns the patched resources.
+func (r *PatchReader) Read() ([]*yaml.RNode, error) {
+	nodes, err := expandSources(r.Expander, r.Resources, r.Sources)
+	if err != nil {
+		return nil, err
+	}
+
+	return (&PatchFilter{Patches: r.Patches}).Filter(nodes)
+}
+
+// PatchFilter applies a list of patches, in order, to the nodes.
+type PatchFilter struct {
+	// The list of patches to apply.
+	Patches []konjurev1beta2.PatchSpec
+}
+
+func (f *PatchFilter) Filter(nodes []*yaml.RNode) ([]*yaml.RNode, error) {
+	var err error
+	for i := range f.Patches {
+		nodes, err = applyPatch(&f.Patches[i], nodes)
+		if err != nil {
+			return nil, fmt.Errorf("patch %d: %w", i, err)
+		}
+	}
+	return nodes, nil
+}
+
+// expandSources fully expands the resource specifications and inline Konjure resources.
+func expandSources(expander *Filter, resources []string, sources []map[string]interface{}) ([]*yaml.RNode, error) {
+	var nodes []*yaml.RNode
+	if len(resources) > 0 {
+		n, err := konjurev1beta2.GetRNode(&konjurev1beta2.Resource{Resources: resources})
+		if err != nil {
+			return nil, err
+		}
+		nodes = append(nodes, n)
+	}
+
+	for _, src := range sources {
+		n, err := yaml.FromMap(src)
+		if err != nil {
+			return nil, err
+		}
+		nodes = append(nodes, n)
+	}
+
+	if expander == nil {
+		expander = &Filter{Depth: 100}
+	}
+	return expander.Filter(nodes)
+}
+
+// applyPatch applies a single patch to the matching nodes, preserving order.
+func applyPatch(p *konjurev1beta2.PatchSpec, nodes []*yaml.RNode) ([]*yaml.RNode, error) {
+	var smp *yaml.RNode
+	if p.StrategicMerge != "" {
+		var err error
+		if smp, err = yaml.Parse(p.StrategicMerge); err != nil {
+			return nil, err
+		}
+	}
+
+	target, err := patchTarget(p, smp)
+	if err != nil {
+		return nil, err
+	}
+
+	matched, err := target.Filter(nodes)
+	if err != nil {
+		return nil, err
+	}
+	selected := make(map[*yaml.RNode]bool, len(matched))
+	for _, n := range matched {
+		selected[n] = true
+	}
+
+	var fs []kio.Filter
+	if smp != nil {
+		fs = append(fs, patchstrategicmerge.Filter{Patch: smp})
+	}
+	if p.JSON6902 != "" {
+		fs = append(fs, patchjson6902.Filter{Patch: p.JSON6902})
+	}
+	if len(p.Values) > 0 {
+		fs = append(fs, kio.FilterAll(filters.SetValues(p.Values, false)))
+	}
+
+	result := make([]*yaml.RNode, 0, len(nodes))
+	for _, n := range nodes {
+		patched := []*yaml.RNode{n}
+		if selected[n] {
+			for _, f := range fs {
+				if patched, err = f.Filter(patched); err != nil {
+					return nil, err
+				}
+			}
+		}
+
+		// A strategic merge patch may delete the node entirely
+		result = append(result, patched...)
+	}
+
+	return result, nil
+}
+
+// patchTarget returns the filter for selecting the nodes to patch. If no target
+// is specified, the identity of the strategic merge patch is used.
+func patchTarget(p *konjurev1beta2.PatchSpec, smp *yaml.RNode) (*filters.ResourceMetaFilter, error) {
+	target := filters.ResourceMetaFilter(p.Target)
+	if p.Target != (konjurev1beta2.Selector{}) || smp == nil {
+		return &target, nil
+	}
+
+	m, err := smp.GetMeta()
+	if err != nil {
+		return nil, err
+	}
+
+	if m.Kind != "" {
+		target.Kind = regexp.QuoteMeta(m.Kind)
+	}
+	if m.Name != "" {
+		target.Name = regexp.QuoteMeta(m.Name)
+	}
+	if m.Namespace != "" {
+		target.Namespace = regexp.QuoteMeta(m.Namespace)
+	}
+	return &target, nil
+}
@@ -0,0 +1,208 @@
+/*
+Copyright 2023 GramLabs, Inc.
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package readers
+
+import (
+	"strings"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+	konjurev1beta2 "github.com/thestormforge/konjure/pkg/api/core/v1beta2"
+	"sigs.k8s.io/kustomize/kyaml/kio"
+	"sigs.k8s.io/kustomize/kyaml/kio/kioutil"
+)
+
+func TestPatchReader_Read(t *testing.T) {
+	dir := t.TempDir()
+	writeFiles(t, dir, map[string]string{
+		"resources.yaml": `apiVersion: apps/v1
+kind: Deployment
+metadata:
+  name: test
+spec:
+  replicas: 1
+  template:
+    spec:
+      containers:
+      - name: app
+        image: app:v1
+---
+apiVersion: v1
+kind: ConfigMap
+metadata:
+  name: test
+data:
+  a: b
+`,
+	})
+
+	cases := []struct {
+		desc     string
+		patches  []konjurev1beta2.PatchSpec
+		expected string
+	}{
+		{
+			desc: "strategic merge",
+			patches: []konjurev1beta2.PatchSpec{
+				{
+					StrategicMerge: `apiVersion: apps/v1
+kind: Deployment
+metadata:
+  name: test
+spec:
+  template:
+    spec:
+      containers:
+      - name: app
+        image: app:v2
+`,
+				},
+			},
+			expected: `apiVersion: apps/v1
+kind: Deployment
+metadata:
+  name: test
+spec:
+  replicas: 1
+  template:
+    spec:
+      containers:
+      - name: app
+        image: app:v2
+---
+apiVersion: v1
+kind: ConfigMap
+metadata:
+  name: test
+data:
+  a: b
+`,
+		},
+		{
+			desc: "strategic merge delete",
+			patches: []konjurev1beta2.PatchSpec{
+				{
+					StrategicMerge: "apiVersion: v1\nkind: ConfigMap\nmetadata:\n  name: test\n$patch: delete\n",
+				},
+			},
+			expected: `apiVersion: apps/v1
+kind: Deployment
+metadata:
+  name: test
+spec:
+  replicas: 1
+  template:
+    spec:
+      containers:
+      - name: app
+        image: app:v1
+`,
+		},
+		{
+			desc: "json 6902",
+			patches: []konjurev1beta2.PatchSpec{
+				{
+					Target:   konjurev1beta2.Selector{Kind: "ConfigMap"},
+					JSON6902: `[{"op": "add", "path": "/data/c", "value": "d"}]`,
+				},
+			},
+			expected: `apiVersion: apps/v1
+kind: Deployment
+metadata:
+  name: test
+spec:
+  replicas: 1
+  template:
+    spec:
+      containers:
+      - name: app
+        image: app:v1
+---
+apiVersion: v1
+data:
+  a: b
+  c: d
+kind: ConfigMap
+metadata:
+  name: test
+`,
+		},
+		{
+			desc: "values",
+			patches: []konjurev1beta2.PatchSpec{
+				{
+					Target: konjurev1beta2.Selector{Kind: "Deployment"},
+					Values: []string{"spec.replicas=3"},
+				},
+			},
+			expected: `apiVersion: apps/v1
+kind: Deployment
+metadata:
+  name: test
+spec:
+  replicas: 3
+  template:
+    spec:
+      containers:
+      - name: app
+        image: app:v1
+---
+apiVersion: v1
+kind: ConfigMap
+metadata:
+  name: test
+data:
+  a: b
+`,
+		},
+	}
+	for _, tc := range cases {
+		t.Run(tc.desc, func(t *testing.T) {
+			r := &PatchReader{
+				Patch: konjurev1beta2.Patch{
+					Sources: []map[string]interface{}{
+						{
+							"apiVersion": konjurev1beta2.APIVersion,
+							"kind":       "File",
+							"path":       dir,
+						},
+					},
+					Patches: tc.patches,
+				},
+			}
+
+			nodes, err := r.Read()
+			require.NoError(t, err)
+
+			var actual strings.Builder
+			err = kio.ByteWriter{
+				Writer: &actual,
+				ClearAnnotations: []string{
+					ProvenanceAnnotation,
+					kioutil.PathAnnotation,
+					kioutil.IndexAnnotation,
+					kioutil.LegacyPathAnnotation,
+					kioutil.LegacyIndexAnnotation,
+				},
+			}.Write(nodes)
+			if assert.NoError(t, err) {
+				assert.Equal(t, tc.expected, actual.String())
+			}
+		})
+	}
+}
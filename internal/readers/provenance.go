@@ -0,0 +1,120 @@
+/*
+Copyright 2023 GramLabs, Inc.
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package readers
+
+import (
+	"encoding/json"
+	"strconv"
+	"strings"
+
+	konjurev1beta2 "github.com/thestormforge/konjure/pkg/api/core/v1beta2"
+	"github.com/thestormforge/konjure/pkg/filters"
+	"sigs.k8s.io/kustomize/kyaml/kio/kioutil"
+	"sigs.k8s.io/kustomize/kyaml/yaml"
+)
+
+// ProvenanceAnnotation is the annotation used to record the chain of Konjure
+// resources a node was expanded from.
+const ProvenanceAnnotation = filters.ProvenanceAnnotation
+
+// Origin describes a single Konjure resource expansion that contributed to a node.
+type Origin = filters.Origin
+
+// GetProvenance returns the chain of origins recorded on the supplied node,
+// starting with the outermost expansion.
+func GetProvenance(node *yaml.RNode) ([]Origin, error) {
+	return filters.GetProvenance(node)
+}
+
+// newOrigin returns the origin for a typed Konjure resource, or nil if the resource is not recognized.
+func newOrigin(res interface{}) *Origin {
+	switch res := res.(type) {
+	case *konjurev1beta2.Resource:
+		return &Origin{Kind: "Resource", Source: strings.Join(res.Resources, ",")}
+	case *konjurev1beta2.Helm:
+		o := &Origin{Kind: "Helm", Source: res.Chart, Ref: res.Version}
+		if res.Repository != "" {
+			o.Source = strings.TrimSuffix(res.Repository, "/") + "/" + res.Chart
+		}
+		if res.Digest != "" {
+			o.Ref = res.Digest
+		}
+		return o
+	case *konjurev1beta2.Jsonnet:
+		return &Origin{Kind: "Jsonnet", Source: res.Filename}
+	case *konjurev1beta2.Kubernetes:
+		return &Origin{Kind: "Kubernetes", Source: strings.Join(res.Types, ",")}
+	case *konjurev1beta2.Kustomize:
+		return &Origin{Kind: "Kustomize", Source: res.Root}
+	case *konjurev1beta2.Secret:
+		return &Origin{Kind: "Secret", Source: res.SecretName}
+	case *konjurev1beta2.ConfigMap:
+		return &Origin{Kind: "ConfigMap", Source: res.ConfigMapName}
+	case *konjurev1beta2.Git:
+		o := &Origin{Kind: "Git", Source: res.Repository, Ref: res.Refspec}
+		if res.Context != "" {
+			o.Source += "//" + res.Context
+		}
+		return o
+	case *konjurev1beta2.HTTP:
+		return &Origin{Kind: "HTTP", Source: res.URL}
+	case *konjurev1beta2.File:
+		return &Origin{Kind: "File", Source: res.Path}
+	case *konjurev1beta2.Patch:
+		return &Origin{Kind: "Patch", Source: strings.Join(res.Resources, ",")}
+	case *konjurev1beta2.Transform:
+		return &Origin{Kind: "Transform", Source: strings.Join(res.Resources, ",")}
+	}
+	return nil
+}
+
+// annotateProvenance appends the origin to the provenance chain of the parent
+// node and records it on each of the expanded nodes. Nodes which already have a
+// chain (e.g. from a nested expansion) keep it after the new origin.
+func annotateProvenance(parent *yaml.RNode, origin *Origin, nodes []*yaml.RNode) error {
+	chain, err := GetProvenance(parent)
+	if err != nil {
+		return err
+	}
+
+	for i, n := range nodes {
+		inner, err := GetProvenance(n)
+		if err != nil {
+			return err
+		}
+
+		// File annotations on a node with a chain belong to the innermost origin
+		o := *origin
+		if len(inner) == 0 {
+			o.File, o.Index, _ = kioutil.GetFileAnnotations(n)
+		}
+		if o.Index == "" {
+			o.Index = strconv.Itoa(i)
+		}
+
+		value, err := json.Marshal(append(append(chain[:len(chain):len(chain)], o), inner...))
+		if err != nil {
+			return err
+		}
+
+		if err := n.PipeE(yaml.SetAnnotation(ProvenanceAnnotation, string(value))); err != nil {
+			return err
+		}
+	}
+
+	return nil
+}
@@ -17,11 +17,13 @@ limitations under the License.
 package readers
 
 import (
+	"context"
 	"errors"
 	"fmt"
 	"os/exec"
 	"path/filepath"
 	"strings"
+	"time"
 
 	konjurev1beta2 "github.com/thestormforge/konjure/pkg/api/core/v1beta2"
 	"sigs.k8s.io/kustomize/kyaml/kio"
@@ -44,12 +46,18 @@ func New(res interface{}) kio.Reader {
 		return &KustomizeReader{Kustomize: *res}
 	case *konjurev1beta2.Secret:
 		return &SecretReader{Secret: *res}
+	case *konjurev1beta2.ConfigMap:
+		return &ConfigMapReader{ConfigMap: *res}
 	case *konjurev1beta2.Git:
 		return &GitReader{Git: *res}
 	case *konjurev1beta2.HTTP:
 		return &HTTPReader{HTTP: *res}
 	case *konjurev1beta2.File:
 		return &FileReader{File: *res}
+	case *konjurev1beta2.Patch:
+		return &PatchReader{Patch: *res}
+	case *konjurev1beta2.Transform:
+		return &TransformReader{Transform: *res}
 	}
 	return nil
 }
@@ -67,15 +75,17 @@ type Runtime struct {
 }
 
 // command returns a new `exec.Cmd` runtime wrapper for the supplied command name.
-func (rt *Runtime) command(defBin string) *command {
+// The command is killed if the context is done before it completes.
+func (rt *Runtime) command(ctx context.Context, defBin string) *command {
 	bin := rt.Bin
 	if bin == "" {
 		bin = defBin
 	}
 
 	return &command{
-		Cmd:      exec.Command(bin),
+		Cmd:      exec.CommandContext(ctx, bin),
 		Executor: rt.Executor,
+		ctx:      ctx,
 	}
 }
 
@@ -83,16 +93,25 @@ func (rt *Runtime) command(defBin string) *command {
 type command struct {
 	*exec.Cmd
 	Executor
+	ctx context.Context
 }
 
 // Output invokes the standard `Cmd.Output` function unless there is an explicit
 // executor configured to handle execution.
 func (cmd *command) Output() ([]byte, error) {
+	var out []byte
+	var err error
 	if cmd.Executor != nil {
-		return cmd.Executor(cmd.Cmd)
+		out, err = cmd.Executor(cmd.Cmd)
+	} else {
+		out, err = cmd.Cmd.Output()
 	}
 
-	return cmd.Cmd.Output()
+	// Report the cancellation instead of the "signal: killed" error
+	if err != nil && cmd.ctx.Err() != nil {
+		return nil, fmt.Errorf("%s: %w", filepath.Base(cmd.Path), cmd.ctx.Err())
+	}
+	return out, err
 }
 
 // Read allows the runtime command to act as a `kio.Reader` assuming the command
@@ -111,3 +130,49 @@ func (cmd *command) Read() ([]*yaml.RNode, error) {
 
 	return kio.FromBytes(out)
 }
+
+// readContext returns the context used for reading, limited by the supplied
+// timeout (if one is specified).
+func readContext(ctx context.Context, timeout string) (context.Context, context.CancelFunc, error) {
+	if ctx == nil {
+		ctx = context.Background()
+	}
+
+	if timeout == "" {
+		ctx, cancel := context.WithCancel(ctx)
+		return ctx, cancel, nil
+	}
+
+	d, err := time.ParseDuration(timeout)
+	if err != nil {
+		return nil, nil, fmt.Errorf("invalid timeout %q: %w", timeout, err)
+	}
+
+	ctx, cancel := context.WithTimeout(ctx, d)
+	return ctx, cancel, nil
+}
+
+// readAsync invokes a read function which does not support cancellation,
+// returning early if the context is done. If the context is done, the read
+// function is abandoned and its result is discarded; read functions must not
+// write to shared state (e.g. the cache or lock) since they may outlive the
+// reader that started them.
+func readAsync(ctx context.Context, read func() ([]*yaml.RNode, error)) ([]*yaml.RNode, error) {
+	type result struct {
+		nodes []*yaml.RNode
+		err   error
+	}
+
+	done := make(chan result, 1)
+	go func() {
+		nodes, err := read()
+		done <- result{nodes: nodes, err: err}
+	}()
+
+	select {
+	case r := <-done:
+		return r.nodes, r.err
+	case <-ctx.Done():
+		return nil, ctx.Err()
+	}
+}
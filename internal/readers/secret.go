@@ -24,6 +24,7 @@ import (
 	"io/ioutil"
 	"os"
 	"path"
+	"path/filepath"
 	"strings"
 	"unicode"
 	"unicode/utf8"
@@ -32,11 +33,15 @@ import (
 	"github.com/oklog/ulid/v2"
 	"github.com/sethvargo/go-password/password"
 	konjurev1beta2 "github.com/thestormforge/konjure/pkg/api/core/v1beta2"
+	"github.com/thestormforge/konjure/pkg/filters"
 	"sigs.k8s.io/kustomize/kyaml/yaml"
 )
 
 type SecretReader struct {
 	konjurev1beta2.Secret
+
+	// Optional tracker used to record the files that were read.
+	Tracker *Tracker
 }
 
 func (r *SecretReader) Read() ([]*yaml.RNode, error) {
@@ -53,6 +58,15 @@ func (r *SecretReader) Read() ([]*yaml.RNode, error) {
 			return nil, err
 		}
 	}
+	if r.AppendHash {
+		// Random values would produce a different name every time
+		if len(r.UUIDSources) > 0 || len(r.ULIDSources) > 0 || len(r.PasswordSources) > 0 {
+			return nil, fmt.Errorf("secret %s cannot append a hash to its name when generating random values", r.SecretName)
+		}
+		if err := n.PipeE(yaml.SetAnnotation(filters.AppendHashAnnotation, "true")); err != nil {
+			return nil, err
+		}
+	}
 
 	// Add all the secret data
 	if err := n.PipeE(yaml.Tee(
@@ -74,13 +88,9 @@ func (r *SecretReader) literals(n *yaml.RNode) (*yaml.RNode, error) {
 		return n, nil
 	}
 
-	m := make(map[string]string)
-	for _, s := range r.LiteralSources {
-		items := strings.SplitN(s, "=", 2)
-		if items[0] == "" || len(items) != 2 {
-			return nil, fmt.Errorf("invalid literal, expected key=value: %s", s)
-		}
-		m[items[0]] = strings.Trim(items[1], `"'`)
+	m, err := literalSources(r.LiteralSources)
+	if err != nil {
+		return nil, err
 	}
 
 	return n, n.LoadMapIntoSecretData(m)
@@ -91,31 +101,9 @@ func (r *SecretReader) files(n *yaml.RNode) (*yaml.RNode, error) {
 		return n, nil
 	}
 
-	m := make(map[string]string)
-	for _, s := range r.FileSources {
-		items := strings.SplitN(s, "=", 3)
-		switch len(items) {
-		case 1:
-			data, err := ioutil.ReadFile(items[0])
-			if err != nil {
-				return nil, err
-			}
-			m[path.Base(items[0])] = string(data)
-
-		case 2:
-			if items[0] == "" || items[1] == "" {
-				return nil, fmt.Errorf("key or file path is missing: %s", s)
-			}
-
-			data, err := ioutil.ReadFile(items[1])
-			if err != nil {
-				return nil, err
-			}
-			m[items[0]] = string(data)
-
-		default:
-			return nil, fmt.Errorf("key names or file paths cannot contain '='")
-		}
+	m, err := fileSources(r.FileSources, r.Tracker)
+	if err != nil {
+		return nil, err
 	}
 
 	return n, n.LoadMapIntoSecretData(m)
@@ -126,35 +114,9 @@ func (r *SecretReader) envs(n *yaml.RNode) (*yaml.RNode, error) {
 		return n, nil
 	}
 
-	m := make(map[string]string)
-	for _, s := range r.EnvSources {
-		data, err := ioutil.ReadFile(s)
-		if err != nil {
-			return nil, err
-		}
-
-		scanner := bufio.NewScanner(bytes.NewReader(bytes.TrimPrefix(data, []byte{0xEF, 0xBB, 0xBF})))
-		currentLine := 0
-		for scanner.Scan() {
-			currentLine++
-
-			line := scanner.Bytes()
-			if !utf8.Valid(line) {
-				return nil, fmt.Errorf("line %d has invalid UTF-8 bytes: %s", currentLine, string(line))
-			}
-
-			line = bytes.TrimLeftFunc(line, unicode.IsSpace)
-			if len(line) == 0 || line[0] == '#' {
-				continue
-			}
-
-			items := strings.SplitN(string(line), "=", 2)
-			if len(items) == 2 {
-				m[items[0]] = items[1]
-			} else {
-				m[items[0]] = os.Getenv(items[0])
-			}
-		}
+	m, err := envSources(r.EnvSources, r.Tracker)
+	if err != nil {
+		return nil, err
 	}
 
 	return n, n.LoadMapIntoSecretData(m)
@@ -246,3 +208,137 @@ func passwordArgs(s *konjurev1beta2.PasswordRecipe) (length int, numDigits int,
 
 	return length, numDigits, numSymbols, noUpper, allowRepeat
 }
+
+// literalSources returns the data for a list of `key=value` pairs.
+func literalSources(sources []string) (map[string]string, error) {
+	m := make(map[string]string)
+	for _, s := range sources {
+		items := strings.SplitN(s, "=", 2)
+		if items[0] == "" || len(items) != 2 {
+			return nil, fmt.Errorf("invalid literal, expected key=value: %s", s)
+		}
+		m[items[0]] = strings.Trim(items[1], `"'`)
+	}
+	return m, nil
+}
+
+// fileSources returns the data for a list of files (or `key=filename` pairs). Files
+// without an explicit key may also be directories or glob patterns.
+func fileSources(sources []string, tracker *Tracker) (map[string]string, error) {
+	m := make(map[string]string)
+	for _, s := range sources {
+		items := strings.SplitN(s, "=", 3)
+		switch len(items) {
+		case 1:
+			filenames, err := expandFileSource(items[0], tracker)
+			if err != nil {
+				return nil, err
+			}
+			for _, filename := range filenames {
+				key := path.Base(filename)
+				if _, ok := m[key]; ok {
+					return nil, fmt.Errorf("duplicate key %q from file: %s", key, filename)
+				}
+
+				data, err := ioutil.ReadFile(filename)
+				if err != nil {
+					return nil, err
+				}
+				tracker.Track(filename)
+				m[key] = string(data)
+			}
+
+		case 2:
+			if items[0] == "" || items[1] == "" {
+				return nil, fmt.Errorf("key or file path is missing: %s", s)
+			}
+
+			data, err := ioutil.ReadFile(items[1])
+			if err != nil {
+				return nil, err
+			}
+			tracker.Track(items[1])
+			m[items[0]] = string(data)
+
+		default:
+			return nil, fmt.Errorf("key names or file paths cannot contain '='")
+		}
+	}
+	return m, nil
+}
+
+// expandFileSource returns the regular files for a file source, which may be a
+// directory (only files directly in the directory are included) or a glob pattern.
+func expandFileSource(name string, tracker *Tracker) ([]string, error) {
+	// Existing files are never treated as patterns, even if the name contains glob characters
+	matches := []string{name}
+	if _, err := os.Stat(name); os.IsNotExist(err) && strings.ContainsAny(name, "*?[") {
+		var err error
+		if matches, err = filepath.Glob(name); err != nil {
+			return nil, err
+		}
+		if len(matches) == 0 {
+			return nil, fmt.Errorf("no files match pattern: %s", name)
+		}
+	}
+
+	var result []string
+	for _, match := range matches {
+		fi, err := os.Stat(match)
+		if err != nil {
+			return nil, err
+		}
+		if !fi.IsDir() {
+			result = append(result, match)
+			continue
+		}
+
+		entries, err := os.ReadDir(match)
+		if err != nil {
+			return nil, err
+		}
+		tracker.Track(match)
+		for _, e := range entries {
+			if e.Type().IsRegular() {
+				result = append(result, filepath.Join(match, e.Name()))
+			}
+		}
+	}
+	return result, nil
+}
+
+// envSources returns the data for a list of .env files.
+func envSources(sources []string, tracker *Tracker) (map[string]string, error) {
+	m := make(map[string]string)
+	for _, s := range sources {
+		data, err := ioutil.ReadFile(s)
+		if err != nil {
+			return nil, err
+		}
+		tracker.Track(s)
+
+		scanner := bufio.NewScanner(bytes.NewReader(bytes.TrimPrefix(data, []byte{0xEF, 0xBB, 0xBF})))
+		currentLine := 0
+		for scanner.Scan() {
+			currentLine++
+
+			line := scanner.Bytes()
+			if !utf8.Valid(line) {
+				return nil, fmt.Errorf("line %d has invalid UTF-8 bytes: %s", currentLine, string(line))
+			}
+
+			line = bytes.TrimLeftFunc(line, unicode.IsSpace)
+			if len(line) == 0 || line[0] == '#' {
+				continue
+			}
+
+			items := strings.SplitN(string(line), "=", 2)
+			if len(items) == 2 {
+				m[items[0]] = items[1]
+			} else {
+				m[items[0]] = os.Getenv(items[0])
+			}
+		}
+	}
+	return m, nil
+}
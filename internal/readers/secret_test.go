@@ -0,0 +1,70 @@
+/*
+Copyright 2023 GramLabs, Inc.
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package readers
+
+import (
+	"path/filepath"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+	konjurev1beta2 "github.com/thestormforge/konjure/pkg/api/core/v1beta2"
+)
+
+func TestSecretReader_Read(t *testing.T) {
+	dir := t.TempDir()
+	writeFiles(t, dir, map[string]string{
+		"key[1].txt": "one",
+		"key?.txt":   "two",
+	})
+
+	r := &SecretReader{
+		Secret: konjurev1beta2.Secret{
+			SecretName: "test",
+			FileSources: []string{
+				filepath.Join(dir, "key[1].txt"),
+				"two=" + filepath.Join(dir, "key?.txt"),
+			},
+		},
+	}
+
+	nodes, err := r.Read()
+	require.NoError(t, err)
+	if assert.Len(t, nodes, 1) {
+		assert.Equal(t, `apiVersion: v1
+kind: Secret
+metadata:
+  name: test
+data:
+  key[1].txt: b25l
+  two: dHdv
+`, nodes[0].MustString())
+	}
+}
+
+func TestSecretReader_Read_appendHash(t *testing.T) {
+	r := &SecretReader{
+		Secret: konjurev1beta2.Secret{
+			SecretName:  "test",
+			AppendHash:  true,
+			UUIDSources: []string{"id"},
+		},
+	}
+
+	_, err := r.Read()
+	assert.EqualError(t, err, "secret test cannot append a hash to its name when generating random values")
+}
@@ -0,0 +1,73 @@
+/*
+Copyright 2023 GramLabs, Inc.
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package readers
+
+import (
+	"os"
+	"path/filepath"
+	"sort"
+	"strings"
+	"sync"
+)
+
+// Tracker records the local files used while reading resources.
+type Tracker struct {
+	mu    sync.Mutex
+	files map[string]struct{}
+}
+
+// Track records the supplied paths. Paths which do not exist locally (e.g.
+// URLs) are ignored; it is safe to call Track on a nil tracker.
+func (t *Tracker) Track(paths ...string) {
+	if t == nil {
+		return
+	}
+
+	t.mu.Lock()
+	defer t.mu.Unlock()
+	for _, path := range paths {
+		if path == "" || strings.Contains(path, "://") {
+			continue
+		}
+		if abs, err := filepath.Abs(path); err == nil {
+			path = abs
+		}
+		if _, err := os.Stat(path); err != nil {
+			continue
+		}
+		if t.files == nil {
+			t.files = make(map[string]struct{})
+		}
+		t.files[path] = struct{}{}
+	}
+}
+
+// Files returns the sorted list of tracked paths.
+func (t *Tracker) Files() []string {
+	if t == nil {
+		return nil
+	}
+
+	t.mu.Lock()
+	defer t.mu.Unlock()
+	result := make([]string, 0, len(t.files))
+	for path := range t.files {
+		result = append(result, path)
+	}
+	sort.Strings(result)
+	return result
+}
@@ -0,0 +1,62 @@
+/*
+Copyright 2023 GramLabs, Inc.
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package readers
+
+import (
+	"os"
+	"path/filepath"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+	konjurev1beta2 "github.com/thestormforge/konjure/pkg/api/core/v1beta2"
+)
+
+func TestTracker(t *testing.T) {
+	dir := t.TempDir()
+	writeFile := func(name, data string) string {
+		name = filepath.Join(dir, name)
+		require.NoError(t, os.WriteFile(name, []byte(data), 0644))
+		return name
+	}
+
+	main := writeFile("main.jsonnet", `(import 'lib.libsonnet') { metadata: { name: importstr 'name.txt' } }`)
+	lib := writeFile("lib.libsonnet", `{ apiVersion: 'v1', kind: 'ConfigMap' }`)
+	name := writeFile("name.txt", `test`)
+	env := writeFile("secret.env", "FOO=bar\n")
+
+	tracker := &Tracker{}
+	track := WithTracker(tracker)
+
+	_, err := track(NewJsonnetReader(&konjurev1beta2.Jsonnet{Filename: main})).Read()
+	require.NoError(t, err)
+
+	_, err = track(&SecretReader{Secret: konjurev1beta2.Secret{
+		SecretName: "test",
+		EnvSources: []string{env},
+	}}).Read()
+	require.NoError(t, err)
+
+	// URLs and missing files are never tracked
+	tracker.Track("https://example.com/values.yaml", filepath.Join(dir, "missing.yaml"))
+
+	assert.Equal(t, []string{lib, main, name, env}, tracker.Files())
+
+	var nilTracker *Tracker
+	nilTracker.Track(main)
+	assert.Empty(t, nilTracker.Files())
+}
@@ -0,0 +1,49 @@
+/*
+Copyright 2023 GramLabs, Inc.
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package readers
+
+import (
+	konjurev1beta2 "github.com/thestormforge/konjure/pkg/api/core/v1beta2"
+	"github.com/thestormforge/konjure/pkg/filters"
+	"sigs.k8s.io/kustomize/kyaml/yaml"
+)
+
+// TransformReader expands the configured sources and applies common transformations to the result.
+type TransformReader struct {
+	konjurev1beta2.Transform
+
+	// The filter used to expand the sources, defaults to the full depth.
+	Expander *Filter
+}
+
+// Read expands the sources and returns the transformed resources.
+func (r *TransformReader) Read() ([]*yaml.RNode, error) {
+	nodes, err := expandSources(r.Expander, r.Resources, r.Sources)
+	if err != nil {
+		return nil, err
+	}
+
+	f := &filters.TransformFilter{
+		Namespace:         r.Namespace,
+		NamePrefix:        r.NamePrefix,
+		NameSuffix:        r.NameSuffix,
+		CommonLabels:      r.CommonLabels,
+		CommonAnnotations: r.CommonAnnotations,
+		Images:            r.Images,
+	}
+	return f.Filter(nodes)
+}
@@ -18,6 +18,7 @@ package spec
 
 import (
 	"fmt"
+	"strings"
 
 	konjurev1beta2 "github.com/thestormforge/konjure/pkg/api/core/v1beta2"
 )
@@ -33,7 +34,21 @@ func (f *Formatter) Encode(obj interface{}) (string, error) {
 		}
 
 	case *konjurev1beta2.Helm:
-		// TODO Should we attempt to make this into a URL?
+		// TODO Should we attempt to make this into a URL for non-OCI repositories?
+		if strings.HasPrefix(s.Repository, "oci://") &&
+			s.ReleaseName == "" &&
+			s.ReleaseNamespace == "" &&
+			len(s.Values) == 0 &&
+			!s.IncludeTests {
+			spec := strings.TrimSuffix(s.Repository, "/") + "/" + s.Chart
+			if s.Version != "" {
+				spec += ":" + s.Version
+			}
+			if s.Digest != "" {
+				spec += "@" + s.Digest
+			}
+			return spec, nil
+		}
 
 	case *konjurev1beta2.Jsonnet:
 		if s.Filename != "" &&
@@ -55,6 +70,9 @@ func (f *Formatter) Encode(obj interface{}) (string, error) {
 	case *konjurev1beta2.Secret:
 		// There is no specification form for secrets
 
+	case *konjurev1beta2.ConfigMap:
+		// There is no specification form for config maps
+
 	case *konjurev1beta2.Git:
 		// TODO This is probably more complex because of all the allowed formats
 
@@ -63,6 +81,15 @@ func (f *Formatter) Encode(obj interface{}) (string, error) {
 
 	case *konjurev1beta2.File:
 		return s.Path, nil
+
+	case *konjurev1beta2.Policy:
+		// There is no specification form for policies
+
+	case *konjurev1beta2.Patch:
+		// There is no specification form for patches
+
+	case *konjurev1beta2.Transform:
+		// There is no specification form for transforms
 	}
 
 	return "", fmt.Errorf("object cannot be formatted")
@@ -93,7 +93,7 @@ func (p *Parser) Decode(spec string) (interface{}, error) {
 			return p.parseGitSpec(spec)
 		case "http", "https":
 			return p.parseHTTPSpec(spec)
-		case "helm":
+		case "helm", "oci":
 			return p.parseHelmSpec(spec)
 		case "k8s":
 			return p.parseKubernetesSpec(spec)
@@ -197,6 +197,14 @@ func (p *Parser) parseHelmSpec(spec string) (interface{}, error) {
 
 	switch {
 
+	case u.Scheme == "oci":
+		// OCI references use the last path segment for the chart name, tag and digest
+		var name string
+		u.Path, name = path.Split(u.Path)
+		name, helm.Digest, _ = strings.Cut(name, "@")
+		helm.Chart, helm.Version, _ = strings.Cut(name, ":")
+		helm.Repository = strings.TrimSuffix(u.String(), "/")
+
 	case u.Host == "artifacthub.io" && strings.HasPrefix(u.Path, "/packages/helm/"):
 		// If this looks like an Artifact Hub URL, try to pull the details via the API
 		if resp, err := http.Get("https://artifacthub.io/api/v1" + u.Path); err == nil && resp.StatusCode == http.StatusOK {
@@ -264,6 +272,12 @@ func (p *Parser) parseKubernetesSpec(spec string) (interface{}, error) {
 	k8s.Selector = u.Query().Get("labelSelector")
 	k8s.AllNamespaces, _ = strconv.ParseBool(u.Query().Get("allNamespaces"))
 	k8s.NamespaceSelector = u.Query().Get("namespaceSelector")
+	k8s.FollowReferences, _ = strconv.Atoi(u.Query().Get("followReferences"))
+	k8s.Export, _ = strconv.ParseBool(u.Query().Get("export"))
+	k8s.RemoveDefaults, _ = strconv.ParseBool(u.Query().Get("removeDefaults"))
+	if excludeTypes := u.Query().Get("excludeTypes"); excludeTypes != "" {
+		k8s.ExcludeTypes = strings.Split(excludeTypes, ",")
+	}
 	k8s.Namespaces = strings.Split(parts[0], ",")
 	if len(k8s.Namespaces) == 1 {
 		k8s.Namespace = k8s.Namespaces[0]
@@ -63,6 +63,26 @@ func TestParser_Decode(t *testing.T) {
 				Namespace: "default",
 			},
 		},
+		{
+			desc: "kubernetes export",
+			spec: "k8s:default/deployments,services?export=true&removeDefaults=true&followReferences=2",
+			expected: &konjurev1beta2.Kubernetes{
+				Namespace:        "default",
+				Types:            []string{"deployments", "services"},
+				FollowReferences: 2,
+				Export:           true,
+				RemoveDefaults:   true,
+			},
+		},
+		{
+			desc: "kubernetes all types",
+			spec: "k8s:default/*?excludeTypes=secrets,events",
+			expected: &konjurev1beta2.Kubernetes{
+				Namespace:    "default",
+				Types:        []string{"*"},
+				ExcludeTypes: []string{"secrets", "events"},
+			},
+		},
 		{
 			desc: "kubernetes all deployments",
 			spec: "k8s:/deployments",
@@ -129,6 +149,24 @@ func TestParser_Decode(t *testing.T) {
 				Repository: "https://charts.bitnami.com/bitnami",
 			},
 		},
+		{
+			desc: "helm oci reference",
+			spec: "oci://registry-1.docker.io/bitnamicharts/nginx:15.0.0",
+			expected: &konjurev1beta2.Helm{
+				Chart:      "nginx",
+				Version:    "15.0.0",
+				Repository: "oci://registry-1.docker.io/bitnamicharts",
+			},
+		},
+		{
+			desc: "helm oci digest",
+			spec: "helm::oci://localhost:5000/charts/example@sha256:0123456789abcdef",
+			expected: &konjurev1beta2.Helm{
+				Chart:      "example",
+				Digest:     "sha256:0123456789abcdef",
+				Repository: "oci://localhost:5000/charts",
+			},
+		},
 
 		// URLs to web blobs shouldn't require a full clone
 		{
@@ -18,7 +18,9 @@ package main
 
 import (
 	"context"
+	"errors"
 	"os"
+	"os/signal"
 	"time"
 
 	"github.com/spf13/cobra"
@@ -38,9 +40,19 @@ func init() {
 func main() {
 	// TODO Wrap `http.DefaultTransport` so it includes the UA string
 
-	ctx := context.Background()
+	// Cancel any in-progress expansions on interrupt
+	ctx, stop := signal.NotifyContext(context.Background(), os.Interrupt)
+	defer stop()
+
 	cmd := command.NewRootCommand(version, commit, date)
 	if err := cmd.ExecuteContext(ctx); err != nil {
-		os.Exit(1)
+		stop()
+
+		code := 1
+		var exitErr *command.ExitError
+		if errors.As(err, &exitErr) {
+			code = exitErr.Code
+		}
+		os.Exit(code)
 	}
 }
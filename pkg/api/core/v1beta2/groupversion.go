@@ -51,12 +51,20 @@ func NewForType(t *yaml.TypeMeta) (interface{}, error) {
 		result = new(Kustomize)
 	case "Secret":
 		result = new(Secret)
+	case "ConfigMap":
+		result = new(ConfigMap)
 	case "Git":
 		result = new(Git)
 	case "HTTP":
 		result = new(HTTP)
 	case "File":
 		result = new(File)
+	case "Policy":
+		result = new(Policy)
+	case "Patch":
+		result = new(Patch)
+	case "Transform":
+		result = new(Transform)
 	default:
 		return nil, fmt.Errorf("unknown kind: %s", t.Kind)
 	}
@@ -105,6 +113,12 @@ func GetRNode(obj interface{}) (*yaml.RNode, error) {
 			Meta *yaml.ResourceMeta `yaml:",inline"`
 			Spec *Secret            `yaml:",inline"`
 		}{Meta: m, Spec: s}
+	case *ConfigMap:
+		m.Kind = "ConfigMap"
+		node = struct {
+			Meta *yaml.ResourceMeta `yaml:",inline"`
+			Spec *ConfigMap         `yaml:",inline"`
+		}{Meta: m, Spec: s}
 	case *Git:
 		m.Kind = "Git"
 		node = struct {
@@ -123,6 +137,24 @@ func GetRNode(obj interface{}) (*yaml.RNode, error) {
 			Meta *yaml.ResourceMeta `yaml:",inline"`
 			Spec *File              `yaml:",inline"`
 		}{Meta: m, Spec: s}
+	case *Policy:
+		m.Kind = "Policy"
+		node = struct {
+			Meta *yaml.ResourceMeta `yaml:",inline"`
+			Spec *Policy            `yaml:",inline"`
+		}{Meta: m, Spec: s}
+	case *Patch:
+		m.Kind = "Patch"
+		node = struct {
+			Meta *yaml.ResourceMeta `yaml:",inline"`
+			Spec *Patch             `yaml:",inline"`
+		}{Meta: m, Spec: s}
+	case *Transform:
+		m.Kind = "Transform"
+		node = struct {
+			Meta *yaml.ResourceMeta `yaml:",inline"`
+			Spec *Transform         `yaml:",inline"`
+		}{Meta: m, Spec: s}
 	default:
 		return nil, fmt.Errorf("unknown type: %T", obj)
 	}
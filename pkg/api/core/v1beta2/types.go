@@ -51,12 +51,16 @@ type Helm struct {
 	Chart string `json:"chart" yaml:"chart"`
 	// The specific version of the chart to use (defaults to the latest release).
 	Version string `json:"version,omitempty" yaml:"version,omitempty"`
-	// The repository URL to get the chart from.
+	// The digest of the chart to use, only applicable to charts stored in an OCI registry.
+	Digest string `json:"digest,omitempty" yaml:"digest,omitempty"`
+	// The repository URL to get the chart from, may be an `oci://` registry URL.
 	Repository string `json:"repo" yaml:"repo"`
 	// The values used to configure the chart.
 	Values []HelmValue `json:"values,omitempty" yaml:"values,omitempty"`
 	// Flag to filter out tests from the results.
 	IncludeTests bool `json:"includeTests,omitempty" yaml:"includeTests,omitempty"`
+	// The maximum amount of time to spend reading the resource (e.g. "30s"), defaults to no limit.
+	Timeout string `json:"timeout,omitempty" yaml:"timeout,omitempty"`
 }
 
 // JsonnetParameter specifies inputs to a Jsonnet program.
@@ -90,6 +94,8 @@ type Jsonnet struct {
 	JsonnetBundlerPackageHome string `json:"jbPkgHome,omitempty" yaml:"jbPkgHome,omitempty"`
 	// Flag to force a Bundler refresh, even if the package home directory is already present.
 	JsonnetBundlerRefresh bool `json:"jbRefresh,omitempty" yaml:"jbRefresh,omitempty"`
+	// The maximum amount of time to spend reading the resource (e.g. "30s"), defaults to no limit.
+	Timeout string `json:"timeout,omitempty" yaml:"timeout,omitempty"`
 }
 
 // Kubernetes is used to expand resources found in a Kubernetes cluster.
@@ -102,18 +108,33 @@ type Kubernetes struct {
 	NamespaceSelector string `json:"namespaceSelector,omitempty" yaml:"namespaceSelector,omitempty"`
 	// True to consider all namespaces, ignoring the other configuration.
 	AllNamespaces bool `json:"allNamespaces,omitempty" yaml:"allNamespaces,omitempty"`
-	// The list of resource types to include. Defaults to "deployments,statefulsets,configmaps".
+	// The list of resource types to include. Defaults to "deployments,statefulsets,configmaps". Use "*" to
+	// include every listable resource type or a category name (e.g. "all") to include the types in that category.
 	Types []string `json:"types,omitempty" yaml:"types,omitempty"`
+	// The list of resource types to exclude when types are discovered using "*" or a category. Defaults to
+	// "events,endpoints,endpointslices.discovery.k8s.io,leases.coordination.k8s.io".
+	ExcludeTypes []string `json:"excludeTypes,omitempty" yaml:"excludeTypes,omitempty"`
 	// A label selector to limit which resources are included. Defaults to "" (match everything).
 	Selector string `json:"selector,omitempty" yaml:"selector,omitempty"`
 	// A field selector to limit which resources are included. Defaults to "" (match everything).
 	FieldSelector string `json:"fieldSelector,omitempty" yaml:"fieldSelector,omitempty"`
+	// The number of times to follow owners, owned children and referenced objects (e.g. ConfigMaps mounted
+	// as volumes or the Services selecting pods) from the selected resources. Defaults to 0 (do not follow).
+	FollowReferences int `json:"followReferences,omitempty" yaml:"followReferences,omitempty"`
+	// Flag indicating that server populated fields should be removed so the resources can be re-applied.
+	Export bool `json:"export,omitempty" yaml:"export,omitempty"`
+	// Flag indicating that exported resources should not include values equal to the API defaults.
+	RemoveDefaults bool `json:"removeDefaults,omitempty" yaml:"removeDefaults,omitempty"`
+	// The maximum amount of time to spend reading the resource (e.g. "30s"), defaults to no limit.
+	Timeout string `json:"timeout,omitempty" yaml:"timeout,omitempty"`
 }
 
 // Kustomize is used to expand kustomizations.
 type Kustomize struct {
 	// The Kustomize root to build.
 	Root string `json:"root" yaml:"root"`
+	// The maximum amount of time to spend reading the resource (e.g. "30s"), defaults to no limit.
+	Timeout string `json:"timeout,omitempty" yaml:"timeout,omitempty"`
 }
 
 // PasswordRecipe is used to configure random password strings for secrets.
@@ -138,6 +159,9 @@ type Secret struct {
 	SecretName string `json:"secretName" yaml:"secretName"`
 	// The type of secret to generate.
 	Type string `json:"type,omitempty" yaml:"type,omitempty"`
+	// Flag indicating that a hash of the contents should be appended to the name (references are updated to match).
+	// Cannot be used with randomly generated values (UUIDs, ULIDs or passwords).
+	AppendHash bool `json:"appendHash,omitempty" yaml:"appendHash,omitempty"`
 
 	// A list of `key=value` pairs to include on the secret.
 	LiteralSources []string `json:"literals,omitempty" yaml:"literals,omitempty"`
@@ -156,6 +180,31 @@ type Secret struct {
 	PasswordOptions *password.GeneratorInput `json:"-" yaml:"-"`
 }
 
+// ConfigMap is used to expand a ConfigMap resource.
+type ConfigMap struct {
+	// The name of the config map to generate.
+	ConfigMapName string `json:"configMapName" yaml:"configMapName"`
+	// Flag indicating that a hash of the contents should be appended to the name (references are updated to match).
+	AppendHash bool `json:"appendHash,omitempty" yaml:"appendHash,omitempty"`
+
+	// A list of `key=value` pairs to include on the config map.
+	LiteralSources []string `json:"literals,omitempty" yaml:"literals,omitempty"`
+	// A list of files (or `key=filename` pairs) to include on the config map. Directories and glob patterns
+	// include every matching file; files that are not valid UTF-8 are included as binary data.
+	FileSources []string `json:"files,omitempty" yaml:"files,omitempty"`
+	// A list of .env files (files containing `key=value` pairs) to include on the config map.
+	EnvSources []string `json:"envs,omitempty" yaml:"envs,omitempty"`
+	// A list of files (or `key=filename` pairs) to include as binary data on the config map.
+	BinaryFileSources []string `json:"binaryFiles,omitempty" yaml:"binaryFiles,omitempty"`
+
+	// Flag indicating the config map should be immutable.
+	Immutable bool `json:"immutable,omitempty" yaml:"immutable,omitempty"`
+	// Labels to include on the config map.
+	Labels map[string]string `json:"labels,omitempty" yaml:"labels,omitempty"`
+	// Annotations to include on the config map.
+	Annotations map[string]string `json:"annotations,omitempty" yaml:"annotations,omitempty"`
+}
+
 // Git is used to expand full or partial Git repositories.
 type Git struct {
 	// The Git repository URL.
@@ -164,12 +213,16 @@ type Git struct {
 	Refspec string `json:"refspec,omitempty" yaml:"refspec,omitempty"`
 	// The subdirectory context to limit the Git repository to.
 	Context string `json:"context,omitempty" yaml:"context,omitempty"`
+	// The maximum amount of time to spend reading the resource (e.g. "30s"), defaults to no limit.
+	Timeout string `json:"timeout,omitempty" yaml:"timeout,omitempty"`
 }
 
 // HTTP is used to expand HTTP resources.
 type HTTP struct {
 	// The HTTP(S) URL to fetch.
 	URL string `json:"url" yaml:"url"`
+	// The maximum amount of time to spend reading the resource (e.g. "30s"), defaults to no limit.
+	Timeout string `json:"timeout,omitempty" yaml:"timeout,omitempty"`
 }
 
 // File is used to expand local file system resources.
@@ -177,3 +230,84 @@ type File struct {
 	// The file (or directory) name to read.
 	Path string `json:"path" yaml:"path"`
 }
+
+// Selector identifies resources using regular expressions and Kubernetes label and annotation selectors.
+type Selector struct {
+	// Regular expression matching the group.
+	Group string `json:"group,omitempty" yaml:"group,omitempty"`
+	// Regular expression matching the version.
+	Version string `json:"version,omitempty" yaml:"version,omitempty"`
+	// Regular expression matching the kind.
+	Kind string `json:"kind,omitempty" yaml:"kind,omitempty"`
+	// Regular expression matching the namespace.
+	Namespace string `json:"namespace,omitempty" yaml:"namespace,omitempty"`
+	// Regular expression matching the name.
+	Name string `json:"name,omitempty" yaml:"name,omitempty"`
+	// Kubernetes selector matching labels.
+	LabelSelector string `json:"labelSelector,omitempty" yaml:"labelSelector,omitempty"`
+	// Kubernetes selector matching annotations.
+	AnnotationSelector string `json:"annotationSelector,omitempty" yaml:"annotationSelector,omitempty"`
+}
+
+// PolicyRule is a CEL expression that must evaluate to true for each matching resource.
+type PolicyRule struct {
+	// The name of the rule.
+	Name string `json:"name" yaml:"name"`
+	// The criteria used to select the resources the rule applies to, defaults to every resource.
+	Match Selector `json:"match,omitempty" yaml:"match,omitempty"`
+	// The CEL expression evaluated against the resource (available as `object`).
+	Expression string `json:"expression" yaml:"expression"`
+	// The message reported when the expression does not evaluate to true.
+	Message string `json:"message,omitempty" yaml:"message,omitempty"`
+	// The severity of a violation, either "deny" (the default) or "warn".
+	Severity string `json:"severity,omitempty" yaml:"severity,omitempty"`
+}
+
+// Policy is used to check the expanded resources.
+type Policy struct {
+	// The list of rules to check.
+	Rules []PolicyRule `json:"rules" yaml:"rules"`
+}
+
+// PatchSpec describes a single modification applied by a `Patch`.
+type PatchSpec struct {
+	// The criteria used to select the resources to patch. Defaults to the resource identified by the strategic
+	// merge patch (if present) or every resource.
+	Target Selector `json:"target,omitempty" yaml:"target,omitempty"`
+	// A strategic merge patch (as a YAML or JSON document) to merge into each target.
+	StrategicMerge string `json:"strategicMerge,omitempty" yaml:"strategicMerge,omitempty"`
+	// A JSON 6902 patch (as a YAML or JSON list of operations) to apply to each target.
+	JSON6902 string `json:"json6902,omitempty" yaml:"json6902,omitempty"`
+	// A list of `path=value` pairs to set on each target.
+	Values []string `json:"values,omitempty" yaml:"values,omitempty"`
+}
+
+// Patch is used to modify the resources expanded from other Konjure resources.
+type Patch struct {
+	// The list of URL-like specifications of the resources to patch.
+	Resources []string `json:"resources,omitempty" yaml:"resources,omitempty"`
+	// Inline Konjure resources (e.g. a `Helm` chart) to expand and patch.
+	Sources []map[string]interface{} `json:"sources,omitempty" yaml:"sources,omitempty"`
+	// The list of patches to apply, in order.
+	Patches []PatchSpec `json:"patches" yaml:"patches"`
+}
+
+// Transform is used to apply common changes to the resources expanded from other Konjure resources.
+type Transform struct {
+	// The list of URL-like specifications of the resources to transform.
+	Resources []string `json:"resources,omitempty" yaml:"resources,omitempty"`
+	// Inline Konjure resources (e.g. a `Helm` chart) to expand and transform.
+	Sources []map[string]interface{} `json:"sources,omitempty" yaml:"sources,omitempty"`
+	// The namespace to set on every namespaced resource.
+	Namespace string `json:"namespace,omitempty" yaml:"namespace,omitempty"`
+	// The prefix to add to the name of every resource.
+	NamePrefix string `json:"namePrefix,omitempty" yaml:"namePrefix,omitempty"`
+	// The suffix to add to the name of every resource.
+	NameSuffix string `json:"nameSuffix,omitempty" yaml:"nameSuffix,omitempty"`
+	// Labels to add to every resource, including selectors and pod templates.
+	CommonLabels map[string]string `json:"commonLabels,omitempty" yaml:"commonLabels,omitempty"`
+	// Annotations to add to every resource, including pod templates.
+	CommonAnnotations map[string]string `json:"commonAnnotations,omitempty" yaml:"commonAnnotations,omitempty"`
+	// A list of `name=ref` image overrides, use a reference starting with ":" or "@" to only change the tag or digest.
+	Images []string `json:"images,omitempty" yaml:"images,omitempty"`
+}
@@ -0,0 +1,75 @@
+/*
+Copyright 2023 GramLabs, Inc.
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package filters
+
+import (
+	"sigs.k8s.io/kustomize/api/hasher"
+	"sigs.k8s.io/kustomize/kyaml/yaml"
+)
+
+// AppendHashAnnotation marks the ConfigMaps and Secrets whose names should
+// include a hash of their contents.
+const AppendHashAnnotation = "konjure.stormforge.io/append-hash"
+
+// ContentHashFilter appends a hash of the contents to the names of the marked
+// ConfigMaps and Secrets and rewrites every reference to them.
+type ContentHashFilter struct{}
+
+// Filter renames the marked nodes and updates the references in all the nodes.
+func (f *ContentHashFilter) Filter(nodes []*yaml.RNode) ([]*yaml.RNode, error) {
+	t := &transformer{TransformFilter: &TransformFilter{}, names: make(map[transformKey]string)}
+	h := &hasher.Hasher{}
+	for _, n := range nodes {
+		if _, ok := n.GetAnnotations()[AppendHashAnnotation]; !ok {
+			continue
+		}
+		if err := n.PipeE(yaml.ClearAnnotation(AppendHashAnnotation)); err != nil {
+			return nil, err
+		}
+
+		meta, err := n.GetMeta()
+		if err != nil {
+			return nil, err
+		}
+		if meta.Kind != "ConfigMap" && meta.Kind != "Secret" {
+			continue
+		}
+
+		hash, err := h.Hash(n)
+		if err != nil {
+			return nil, err
+		}
+
+		name := meta.Name + "-" + hash
+		if err := n.PipeE(yaml.SetK8sName(name)); err != nil {
+			return nil, err
+		}
+		t.names[transformKey{kind: meta.Kind, namespace: meta.Namespace, name: meta.Name}] = name
+	}
+
+	if len(t.names) == 0 {
+		return nodes, nil
+	}
+
+	for _, n := range nodes {
+		if err := apply(n, t.references(n.GetKind(), n.GetNamespace(), n)); err != nil {
+			return nil, err
+		}
+	}
+
+	return nodes, nil
+}
@@ -0,0 +1,114 @@
+/*
+Copyright 2023 GramLabs, Inc.
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package filters
+
+import (
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+	"sigs.k8s.io/kustomize/kyaml/kio"
+)
+
+func TestContentHashFilter_Filter(t *testing.T) {
+	nodes, err := kio.FromBytes([]byte(`apiVersion: v1
+kind: ConfigMap
+metadata:
+  name: config
+  annotations:
+    konjure.stormforge.io/append-hash: "true"
+data:
+  a: b
+---
+apiVersion: v1
+kind: Secret
+metadata:
+  name: other
+data:
+  c: ZA==
+---
+apiVersion: apps/v1
+kind: Deployment
+metadata:
+  name: web
+spec:
+  template:
+    spec:
+      containers:
+      - name: web
+        envFrom:
+        - configMapRef:
+            name: config
+        - secretRef:
+            name: other
+      volumes:
+      - name: config
+        configMap:
+          name: config
+      - name: projected
+        projected:
+          sources:
+          - configMap:
+              name: config
+`))
+	require.NoError(t, err)
+
+	actual, err := (&ContentHashFilter{}).Filter(nodes)
+	require.NoError(t, err)
+
+	out, err := kio.StringAll(actual)
+	if assert.NoError(t, err) {
+		assert.Equal(t, `apiVersion: v1
+kind: ConfigMap
+metadata:
+  name: config-4h2mbtbbt6
+data:
+  a: b
+---
+apiVersion: v1
+kind: Secret
+metadata:
+  name: other
+data:
+  c: ZA==
+---
+apiVersion: apps/v1
+kind: Deployment
+metadata:
+  name: web
+spec:
+  template:
+    spec:
+      containers:
+      - name: web
+        envFrom:
+        - configMapRef:
+            name: config-4h2mbtbbt6
+        - secretRef:
+            name: other
+      volumes:
+      - name: config
+        configMap:
+          name: config-4h2mbtbbt6
+      - name: projected
+        projected:
+          sources:
+          - configMap:
+              name: config-4h2mbtbbt6
+`, out)
+	}
+}
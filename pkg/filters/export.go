@@ -0,0 +1,291 @@
+/*
+Copyright 2023 GramLabs, Inc.
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package filters
+
+import (
+	"strings"
+
+	"sigs.k8s.io/kustomize/kyaml/yaml"
+)
+
+// ExportFilter removes the fields populated by the server from resources read
+// from a cluster, producing manifests which can be re-applied.
+type ExportFilter struct {
+	// Flag indicating if this filter should act as a pass-through.
+	Enabled bool
+	// Flag indicating that values equal to well-known API defaults should also be removed.
+	RemoveDefaults bool
+}
+
+// Filter removes the server populated fields from all the nodes.
+func (f *ExportFilter) Filter(nodes []*yaml.RNode) ([]*yaml.RNode, error) {
+	if !f.Enabled {
+		return nodes, nil
+	}
+
+	for _, n := range nodes {
+		if err := export(n); err != nil {
+			return nil, err
+		}
+
+		if f.RemoveDefaults {
+			if err := removeDefaults(n); err != nil {
+				return nil, err
+			}
+		}
+	}
+
+	return nodes, nil
+}
+
+// export removes the server populated fields from a single node.
+func export(n *yaml.RNode) error {
+	fns := []yaml.Filter{
+		yaml.Clear("status"),
+		clearField([]string{yaml.MetadataField}, "managedFields", "uid", "resourceVersion", "creationTimestamp", "generation", "selfLink"),
+		clearField([]string{yaml.MetadataField, yaml.AnnotationsField}, "kubectl.kubernetes.io/last-applied-configuration", "deployment.kubernetes.io/revision"),
+		clearEmpty([]string{yaml.MetadataField}, yaml.AnnotationsField),
+	}
+
+	// Pod templates always include a null creation timestamp
+	for _, p := range podTemplatePaths(n) {
+		fns = append(fns, clearField(append(p, yaml.MetadataField), "creationTimestamp"))
+	}
+
+	// The cluster IP is allocated by the server, unless the service is headless
+	if n.GetApiVersion() == "v1" && n.GetKind() == "Service" {
+		if clusterIP, _ := n.GetString("spec.clusterIP"); clusterIP != "None" {
+			fns = append(fns, clearField([]string{"spec"}, "clusterIP", "clusterIPs"))
+		}
+		fns = append(fns, yaml.Tee(yaml.Lookup("spec", "ports"), visitElements(clearField(nil, "nodePort"))))
+	}
+
+	// The selector and pod labels of a job are generated from the UID of the job
+	if n.GetApiVersion() == "batch/v1" && n.GetKind() == "Job" {
+		if manual, _ := n.GetString("spec.manualSelector"); manual != "true" {
+			labels := []string{"spec", "template", yaml.MetadataField}
+			fns = append(fns,
+				clearField([]string{"spec"}, "selector"),
+				clearField(append(labels, yaml.LabelsField), "controller-uid", "batch.kubernetes.io/controller-uid", "job-name", "batch.kubernetes.io/job-name"),
+				clearEmpty(labels, yaml.LabelsField),
+			)
+		}
+	}
+
+	return apply(n, fns)
+}
+
+// removeDefaults removes values equal to the defaults applied by the server.
+func removeDefaults(n *yaml.RNode) error {
+	var fns []yaml.Filter
+
+	switch n.GetApiVersion() + "/" + n.GetKind() {
+	case "apps/v1/Deployment":
+		fns = append(fns,
+			clearDefault([]string{"spec"}, "revisionHistoryLimit", "10"),
+			clearDefault([]string{"spec"}, "progressDeadlineSeconds", "600"),
+			clearDefault([]string{"spec", "strategy", "rollingUpdate"}, "maxSurge", "25%"),
+			clearDefault([]string{"spec", "strategy", "rollingUpdate"}, "maxUnavailable", "25%"),
+			clearEmpty([]string{"spec", "strategy"}, "rollingUpdate"),
+			clearDefault([]string{"spec", "strategy"}, "type", "RollingUpdate"),
+			clearEmpty([]string{"spec"}, "strategy"),
+		)
+
+	case "apps/v1/StatefulSet":
+		fns = append(fns,
+			clearDefault([]string{"spec"}, "revisionHistoryLimit", "10"),
+			clearDefault([]string{"spec"}, "podManagementPolicy", "OrderedReady"),
+			clearDefault([]string{"spec", "updateStrategy", "rollingUpdate"}, "partition", "0"),
+			clearEmpty([]string{"spec", "updateStrategy"}, "rollingUpdate"),
+			clearDefault([]string{"spec", "updateStrategy"}, "type", "RollingUpdate"),
+			clearEmpty([]string{"spec"}, "updateStrategy"),
+			clearDefault([]string{"spec", "persistentVolumeClaimRetentionPolicy"}, "whenDeleted", "Retain"),
+			clearDefault([]string{"spec", "persistentVolumeClaimRetentionPolicy"}, "whenScaled", "Retain"),
+			clearEmpty([]string{"spec"}, "persistentVolumeClaimRetentionPolicy"),
+		)
+
+	case "apps/v1/DaemonSet":
+		fns = append(fns,
+			clearDefault([]string{"spec"}, "revisionHistoryLimit", "10"),
+			clearDefault([]string{"spec", "updateStrategy", "rollingUpdate"}, "maxSurge", "0"),
+			clearDefault([]string{"spec", "updateStrategy", "rollingUpdate"}, "maxUnavailable", "1"),
+			clearEmpty([]string{"spec", "updateStrategy"}, "rollingUpdate"),
+			clearDefault([]string{"spec", "updateStrategy"}, "type", "RollingUpdate"),
+			clearEmpty([]string{"spec"}, "updateStrategy"),
+		)
+
+	case "v1/Service":
+		fns = append(fns,
+			clearDefault([]string{"spec"}, "type", "ClusterIP"),
+			clearDefault([]string{"spec"}, "sessionAffinity", "None"),
+			clearIPFamilies,
+			clearDefault([]string{"spec"}, "ipFamilyPolicy", "SingleStack"),
+			clearDefault([]string{"spec"}, "internalTrafficPolicy", "Cluster"),
+			yaml.Tee(yaml.Lookup("spec", "ports"), visitElements(
+				clearDefault(nil, "protocol", "TCP"),
+				clearTargetPort,
+			)),
+		)
+	}
+
+	for _, p := range podTemplatePaths(n) {
+		spec := append(p[:len(p):len(p)], "spec")
+		fns = append(fns,
+			clearDefault(spec, "dnsPolicy", "ClusterFirst"),
+			clearDefault(spec, "schedulerName", "default-scheduler"),
+			clearDefault(spec, "terminationGracePeriodSeconds", "30"),
+			clearEmpty(spec, "securityContext"),
+		)
+		if n.GetKind() != "Job" && n.GetKind() != "CronJob" {
+			fns = append(fns, clearDefault(spec, "restartPolicy", "Always"))
+		}
+
+		for _, containers := range []string{"initContainers", "containers"} {
+			fns = append(fns, yaml.Tee(yaml.Lookup(append(spec[:len(spec):len(spec)], containers)...), visitElements(
+				clearDefault(nil, "terminationMessagePath", "/dev/termination-log"),
+				clearDefault(nil, "terminationMessagePolicy", "File"),
+				clearImagePullPolicy,
+				clearEmpty(nil, "resources"),
+				yaml.Tee(yaml.Lookup("ports"), visitElements(clearDefault(nil, "protocol", "TCP"))),
+				clearProbeDefaults("livenessProbe"),
+				clearProbeDefaults("readinessProbe"),
+				clearProbeDefaults("startupProbe"),
+			)))
+		}
+	}
+
+	return apply(n, fns)
+}
+
+// podTemplatePaths returns the paths to the pod templates of the node; the
+// path to a pod itself is empty.
+func podTemplatePaths(n *yaml.RNode) [][]string {
+	switch n.GetKind() {
+	case "Pod":
+		return [][]string{{}}
+	case "Deployment", "StatefulSet", "DaemonSet", "ReplicaSet", "Job", "ReplicationController":
+		return [][]string{{"spec", "template"}}
+	case "CronJob":
+		return [][]string{{"spec", "jobTemplate", "spec", "template"}}
+	}
+	return nil
+}
+
+// clearProbeDefaults returns a filter that removes the default values of a container probe.
+func clearProbeDefaults(probe string) yaml.Filter {
+	return yaml.FilterFunc(func(object *yaml.RNode) (*yaml.RNode, error) {
+		return object, apply(object, []yaml.Filter{
+			clearDefault([]string{probe}, "timeoutSeconds", "1"),
+			clearDefault([]string{probe}, "periodSeconds", "10"),
+			clearDefault([]string{probe}, "successThreshold", "1"),
+			clearDefault([]string{probe}, "failureThreshold", "3"),
+			clearDefault([]string{probe, "httpGet"}, "scheme", "HTTP"),
+		})
+	})
+}
+
+// clearImagePullPolicy removes the image pull policy of a container if it is the default for the image.
+var clearImagePullPolicy = yaml.FilterFunc(func(object *yaml.RNode) (*yaml.RNode, error) {
+	image, _ := object.GetString("image")
+	policy := "IfNotPresent"
+	if name := image[strings.LastIndex(image, "/")+1:]; !strings.Contains(name, ":") && !strings.Contains(name, "@") || strings.HasSuffix(name, ":latest") {
+		policy = "Always"
+	}
+	return object, apply(object, []yaml.Filter{clearDefault(nil, "imagePullPolicy", policy)})
+})
+
+// clearTargetPort removes the target port of a service port if it is the same as the port.
+var clearTargetPort = yaml.FilterFunc(func(object *yaml.RNode) (*yaml.RNode, error) {
+	port := object.Field("port")
+	if port == nil {
+		return object, nil
+	}
+	return object, apply(object, []yaml.Filter{clearDefault(nil, "targetPort", port.Value.YNode().Value)})
+})
+
+// clearIPFamilies removes the IP families of a single stack service since they are assigned by the server.
+var clearIPFamilies = yaml.FilterFunc(func(object *yaml.RNode) (*yaml.RNode, error) {
+	policy, _ := object.GetString("spec.ipFamilyPolicy")
+	if policy != "" && policy != "SingleStack" {
+		return object, nil
+	}
+	return object.Pipe(clearField([]string{"spec"}, "ipFamilies"))
+})
+
+// clearField returns a filter that removes the named fields from the map at the specified path.
+func clearField(path []string, names ...string) yaml.Filter {
+	return yaml.FilterFunc(func(object *yaml.RNode) (*yaml.RNode, error) {
+		m, err := object.Pipe(yaml.Lookup(path...))
+		if err != nil || m == nil {
+			return nil, err
+		}
+		for _, name := range names {
+			if _, err := m.Pipe(yaml.Clear(name)); err != nil {
+				return nil, err
+			}
+		}
+		return object, nil
+	})
+}
+
+// clearEmpty returns a filter that removes the named field from the map at the specified path if it is empty.
+func clearEmpty(path []string, name string) yaml.Filter {
+	return yaml.FilterFunc(func(object *yaml.RNode) (*yaml.RNode, error) {
+		m, err := object.Pipe(yaml.Lookup(path...))
+		if err != nil || m == nil {
+			return nil, err
+		}
+		if f := m.Field(name); f != nil && (yaml.IsMissingOrNull(f.Value) || len(f.Value.Content()) == 0 && f.Value.YNode().Kind != yaml.ScalarNode) {
+			return m.Pipe(yaml.Clear(name))
+		}
+		return object, nil
+	})
+}
+
+// clearDefault returns a filter that removes the named field from the map at the specified path if it
+// has the default value.
+func clearDefault(path []string, name, value string) yaml.Filter {
+	return yaml.FilterFunc(func(object *yaml.RNode) (*yaml.RNode, error) {
+		m, err := object.Pipe(yaml.Lookup(path...))
+		if err != nil || m == nil {
+			return nil, err
+		}
+		if f := m.Field(name); f != nil && f.Value.YNode().Kind == yaml.ScalarNode && f.Value.YNode().Value == value {
+			return m.Pipe(yaml.Clear(name))
+		}
+		return object, nil
+	})
+}
+
+// visitElements returns a filter which applies the supplied filters to each element of a sequence.
+func visitElements(fns ...yaml.Filter) yaml.Filter {
+	return yaml.FilterFunc(func(object *yaml.RNode) (*yaml.RNode, error) {
+		return object, object.VisitElements(func(node *yaml.RNode) error {
+			return apply(node, fns)
+		})
+	})
+}
+
+// apply invokes each filter independently on the supplied node.
+func apply(n *yaml.RNode, fns []yaml.Filter) error {
+	for _, fn := range fns {
+		if _, err := n.Pipe(fn); err != nil {
+			return err
+		}
+	}
+	return nil
+}
@@ -0,0 +1,330 @@
+/*
+Copyright 2023 GramLabs, Inc.
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package filters
+
+import (
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"sigs.k8s.io/kustomize/kyaml/yaml"
+)
+
+func TestExportFilter_Filter(t *testing.T) {
+	deployment := `apiVersion: apps/v1
+kind: Deployment
+metadata:
+  name: test
+  namespace: default
+  uid: 7d3c5f5e-0f0e-4d5e-9d8b-6a7b8c9d0e1f
+  resourceVersion: "1234"
+  generation: 2
+  creationTimestamp: "2023-01-01T00:00:00Z"
+  managedFields:
+  - manager: kubectl
+  annotations:
+    deployment.kubernetes.io/revision: "2"
+    kubectl.kubernetes.io/last-applied-configuration: |
+      {}
+  labels:
+    app: test
+spec:
+  progressDeadlineSeconds: 600
+  replicas: 1
+  revisionHistoryLimit: 10
+  strategy:
+    rollingUpdate:
+      maxSurge: 25%
+      maxUnavailable: 25%
+    type: RollingUpdate
+  template:
+    metadata:
+      creationTimestamp: null
+      labels:
+        app: test
+    spec:
+      containers:
+      - image: nginx:1.25
+        imagePullPolicy: IfNotPresent
+        name: nginx
+        ports:
+        - containerPort: 80
+          protocol: TCP
+        readinessProbe:
+          httpGet:
+            path: /
+            port: 80
+            scheme: HTTP
+          failureThreshold: 3
+          periodSeconds: 10
+          successThreshold: 1
+          timeoutSeconds: 5
+        resources: {}
+        terminationMessagePath: /dev/termination-log
+        terminationMessagePolicy: File
+      dnsPolicy: ClusterFirst
+      restartPolicy: Always
+      schedulerName: default-scheduler
+      securityContext: {}
+      terminationGracePeriodSeconds: 30
+status:
+  replicas: 1
+`
+	service := `apiVersion: v1
+kind: Service
+metadata:
+  name: test
+  uid: 0a1b2c3d-0f0e-4d5e-9d8b-6a7b8c9d0e1f
+spec:
+  clusterIP: 10.0.0.1
+  clusterIPs:
+  - 10.0.0.1
+  internalTrafficPolicy: Cluster
+  ipFamilies:
+  - IPv4
+  ipFamilyPolicy: SingleStack
+  ports:
+  - port: 80
+    protocol: TCP
+    targetPort: 80
+  - port: 443
+    protocol: TCP
+    targetPort: 8443
+  selector:
+    app: test
+  sessionAffinity: None
+  type: ClusterIP
+status:
+  loadBalancer: {}
+`
+
+	cases := []struct {
+		desc     string
+		filter   ExportFilter
+		input    string
+		expected string
+	}{
+		{
+			desc:     "disabled",
+			input:    service,
+			expected: service,
+		},
+		{
+			desc:   "export deployment",
+			filter: ExportFilter{Enabled: true},
+			input:  deployment,
+			expected: `apiVersion: apps/v1
+kind: Deployment
+metadata:
+  name: test
+  namespace: default
+  labels:
+    app: test
+spec:
+  progressDeadlineSeconds: 600
+  replicas: 1
+  revisionHistoryLimit: 10
+  strategy:
+    rollingUpdate:
+      maxSurge: 25%
+      maxUnavailable: 25%
+    type: RollingUpdate
+  template:
+    metadata:
+      labels:
+        app: test
+    spec:
+      containers:
+      - image: nginx:1.25
+        imagePullPolicy: IfNotPresent
+        name: nginx
+        ports:
+        - containerPort: 80
+          protocol: TCP
+        readinessProbe:
+          httpGet:
+            path: /
+            port: 80
+            scheme: HTTP
+          failureThreshold: 3
+          periodSeconds: 10
+          successThreshold: 1
+          timeoutSeconds: 5
+        resources: {}
+        terminationMessagePath: /dev/termination-log
+        terminationMessagePolicy: File
+      dnsPolicy: ClusterFirst
+      restartPolicy: Always
+      schedulerName: default-scheduler
+      securityContext: {}
+      terminationGracePeriodSeconds: 30
+`,
+		},
+		{
+			desc:   "remove deployment defaults",
+			filter: ExportFilter{Enabled: true, RemoveDefaults: true},
+			input:  deployment,
+			expected: `apiVersion: apps/v1
+kind: Deployment
+metadata:
+  name: test
+  namespace: default
+  labels:
+    app: test
+spec:
+  replicas: 1
+  template:
+    metadata:
+      labels:
+        app: test
+    spec:
+      containers:
+      - image: nginx:1.25
+        name: nginx
+        ports:
+        - containerPort: 80
+        readinessProbe:
+          httpGet:
+            path: /
+            port: 80
+          timeoutSeconds: 5
+`,
+		},
+		{
+			desc:   "remove service defaults",
+			filter: ExportFilter{Enabled: true, RemoveDefaults: true},
+			input:  service,
+			expected: `apiVersion: v1
+kind: Service
+metadata:
+  name: test
+spec:
+  ports:
+  - port: 80
+  - port: 443
+    targetPort: 8443
+  selector:
+    app: test
+`,
+		},
+		{
+			desc:   "export headless service",
+			filter: ExportFilter{Enabled: true},
+			input: `apiVersion: v1
+kind: Service
+metadata:
+  name: test
+  uid: 7d3c5f5e-0f0e-4d5e-9d8b-6a7b8c9d0e1f
+spec:
+  clusterIP: None
+  clusterIPs:
+  - None
+  selector:
+    app: test
+`,
+			expected: `apiVersion: v1
+kind: Service
+metadata:
+  name: test
+spec:
+  clusterIP: None
+  clusterIPs:
+  - None
+  selector:
+    app: test
+`,
+		},
+		{
+			desc:   "export node port service",
+			filter: ExportFilter{Enabled: true},
+			input: `apiVersion: v1
+kind: Service
+metadata:
+  name: test
+spec:
+  type: NodePort
+  clusterIP: 10.96.0.10
+  ports:
+  - port: 80
+    nodePort: 30080
+  selector:
+    app: test
+`,
+			expected: `apiVersion: v1
+kind: Service
+metadata:
+  name: test
+spec:
+  type: NodePort
+  ports:
+  - port: 80
+  selector:
+    app: test
+`,
+		},
+		{
+			desc:   "export job",
+			filter: ExportFilter{Enabled: true},
+			input: `apiVersion: batch/v1
+kind: Job
+metadata:
+  name: test
+  uid: 7d3c5f5e-0f0e-4d5e-9d8b-6a7b8c9d0e1f
+spec:
+  selector:
+    matchLabels:
+      batch.kubernetes.io/controller-uid: 7d3c5f5e-0f0e-4d5e-9d8b-6a7b8c9d0e1f
+  template:
+    metadata:
+      creationTimestamp: null
+      labels:
+        app: test
+        batch.kubernetes.io/controller-uid: 7d3c5f5e-0f0e-4d5e-9d8b-6a7b8c9d0e1f
+        batch.kubernetes.io/job-name: test
+        controller-uid: 7d3c5f5e-0f0e-4d5e-9d8b-6a7b8c9d0e1f
+        job-name: test
+    spec:
+      containers:
+      - name: test
+        image: busybox
+      restartPolicy: Never
+`,
+			expected: `apiVersion: batch/v1
+kind: Job
+metadata:
+  name: test
+spec:
+  template:
+    metadata:
+      labels:
+        app: test
+    spec:
+      containers:
+      - name: test
+        image: busybox
+      restartPolicy: Never
+`,
+		},
+	}
+	for _, c := range cases {
+		t.Run(c.desc, func(t *testing.T) {
+			actual, err := c.filter.Filter([]*yaml.RNode{yaml.MustParse(c.input)})
+			if assert.NoError(t, err) && assert.Len(t, actual, 1) {
+				assert.Equal(t, c.expected, actual[0].MustString())
+			}
+		})
+	}
+}
@@ -0,0 +1,56 @@
+/*
+Copyright 2023 GramLabs, Inc.
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package filters
+
+import (
+	"encoding/json"
+
+	"sigs.k8s.io/kustomize/kyaml/yaml"
+)
+
+// ProvenanceAnnotation is the annotation used to record the chain of Konjure
+// resources a node was expanded from.
+const ProvenanceAnnotation = "konjure.stormforge.io/provenance"
+
+// Origin describes a single Konjure resource expansion that contributed to a node.
+type Origin struct {
+	// The kind of Konjure resource that was expanded (e.g. "Helm" or "Git").
+	Kind string `json:"kind"`
+	// The URL or path of the expanded resource.
+	Source string `json:"source,omitempty"`
+	// The reference or version of the expanded resource.
+	Ref string `json:"ref,omitempty"`
+	// The file the node was read from, if any.
+	File string `json:"file,omitempty"`
+	// The index of the node in the file (or in the expansion, if there was no file).
+	Index string `json:"index,omitempty"`
+}
+
+// GetProvenance returns the chain of origins recorded on the supplied node,
+// starting with the outermost expansion.
+func GetProvenance(node *yaml.RNode) ([]Origin, error) {
+	value := node.GetAnnotations()[ProvenanceAnnotation]
+	if value == "" {
+		return nil, nil
+	}
+
+	var origins []Origin
+	if err := json.Unmarshal([]byte(value), &origins); err != nil {
+		return nil, err
+	}
+	return origins, nil
+}
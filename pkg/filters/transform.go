@@ -0,0 +1,362 @@
+/*
+Copyright 2023 GramLabs, Inc.
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package filters
+
+import (
+	"sort"
+	"strings"
+
+	"sigs.k8s.io/kustomize/kyaml/yaml"
+)
+
+// TransformFilter applies common transformations to every resource, updating
+// the references between resources when names or namespaces change.
+type TransformFilter struct {
+	// The namespace to set on every namespaced resource.
+	Namespace string `json:"namespace,omitempty" yaml:"namespace,omitempty"`
+	// The prefix to add to the name of every resource.
+	NamePrefix string `json:"namePrefix,omitempty" yaml:"namePrefix,omitempty"`
+	// The suffix to add to the name of every resource.
+	NameSuffix string `json:"nameSuffix,omitempty" yaml:"nameSuffix,omitempty"`
+	// Labels to add to every resource, including selectors and pod templates.
+	CommonLabels map[string]string `json:"commonLabels,omitempty" yaml:"commonLabels,omitempty"`
+	// Annotations to add to every resource, including pod templates.
+	CommonAnnotations map[string]string `json:"commonAnnotations,omitempty" yaml:"commonAnnotations,omitempty"`
+	// A list of `name=ref` image overrides. If the reference starts with ":" or "@"
+	// only the tag or digest is replaced.
+	Images []string `json:"images,omitempty" yaml:"images,omitempty"`
+}
+
+// Filter applies the transformations to all the nodes.
+func (f *TransformFilter) Filter(nodes []*yaml.RNode) ([]*yaml.RNode, error) {
+	if f.Namespace == "" && f.NamePrefix == "" && f.NameSuffix == "" &&
+		len(f.CommonLabels) == 0 && len(f.CommonAnnotations) == 0 && len(f.Images) == 0 {
+		return nodes, nil
+	}
+
+	// Index the resources before anything changes so references can be resolved
+	t := &transformer{
+		TransformFilter: f,
+		known:           make(map[transformKey]bool, len(nodes)),
+		names:           make(map[transformKey]string, len(nodes)),
+	}
+	for _, n := range nodes {
+		key := transformKey{kind: n.GetKind(), namespace: n.GetNamespace(), name: n.GetName()}
+		t.known[key] = true
+		if (f.NamePrefix != "" || f.NameSuffix != "") && key.name != "" && renamable(key.kind) {
+			t.names[key] = f.NamePrefix + key.name + f.NameSuffix
+		}
+	}
+
+	for _, n := range nodes {
+		if err := t.transform(n); err != nil {
+			return nil, err
+		}
+	}
+
+	return nodes, nil
+}
+
+// transformKey identifies a resource by its original kind, namespace and name.
+type transformKey struct {
+	kind, namespace, name string
+}
+
+// transformer applies the transformations to individual nodes.
+type transformer struct {
+	*TransformFilter
+	known map[transformKey]bool
+	names map[transformKey]string
+}
+
+// transform applies all the transformations to a single node.
+func (t *transformer) transform(n *yaml.RNode) error {
+	meta, err := n.GetMeta()
+	if err != nil {
+		return err
+	}
+
+	// References are resolved using the original namespace of the node
+	fns := t.references(meta.Kind, meta.Namespace, n)
+	fns = append(fns, t.images(n)...)
+
+	if name, ok := t.names[transformKey{kind: meta.Kind, namespace: meta.Namespace, name: meta.Name}]; ok {
+		fns = append(fns, yaml.SetK8sName(name))
+	}
+
+	if t.Namespace != "" {
+		fns = append(fns, SetNamespace(t.Namespace))
+	}
+
+	for _, k := range sortedKeys(t.CommonLabels) {
+		fns = append(fns, setField([]string{yaml.MetadataField, yaml.LabelsField}, k, t.CommonLabels[k]))
+		for _, p := range selectorPaths(n) {
+			fns = append(fns, setIfExists(p, k, t.CommonLabels[k]))
+		}
+		for _, p := range podTemplatePaths(n) {
+			if len(p) > 0 {
+				fns = append(fns, setField(subPath(p, yaml.MetadataField, yaml.LabelsField), k, t.CommonLabels[k]))
+			}
+		}
+	}
+
+	for _, k := range sortedKeys(t.CommonAnnotations) {
+		fns = append(fns, setField([]string{yaml.MetadataField, yaml.AnnotationsField}, k, t.CommonAnnotations[k]))
+		for _, p := range podTemplatePaths(n) {
+			if len(p) > 0 {
+				fns = append(fns, setField(subPath(p, yaml.MetadataField, yaml.AnnotationsField), k, t.CommonAnnotations[k]))
+			}
+		}
+	}
+
+	return apply(n, fns)
+}
+
+// rename returns the new name of a referenced resource.
+func (t *transformer) rename(kind, namespace, name string) string {
+	if newName, ok := t.names[transformKey{kind: kind, namespace: namespace, name: name}]; ok {
+		return newName
+	}
+	return name
+}
+
+// references returns the filters used to update the references to other resources.
+func (t *transformer) references(kind, namespace string, n *yaml.RNode) []yaml.Filter {
+	if len(t.names) == 0 && t.Namespace == "" {
+		return nil
+	}
+
+	ref := func(refKind string, path ...string) yaml.Filter {
+		return t.setRef(refKind, namespace, path...)
+	}
+
+	var fns []yaml.Filter
+	for _, p := range podTemplatePaths(n) {
+		spec := subPath(p, "spec")
+		fns = append(fns,
+			lookup(spec, ref("ServiceAccount", "serviceAccountName"), ref("ServiceAccount", "serviceAccount")),
+			lookup(subPath(spec, "imagePullSecrets"), visitElements(ref("Secret", "name"))),
+			lookup(subPath(spec, "volumes"), visitElements(
+				ref("ConfigMap", "configMap", "name"),
+				ref("Secret", "secret", "secretName"),
+				ref("PersistentVolumeClaim", "persistentVolumeClaim", "claimName"),
+				lookup([]string{"projected", "sources"}, visitElements(
+					ref("ConfigMap", "configMap", "name"),
+					ref("Secret", "secret", "name"),
+				)),
+			)),
+		)
+		for _, c := range []string{"containers", "initContainers"} {
+			fns = append(fns, lookup(subPath(spec, c), visitElements(
+				lookup([]string{"env"}, visitElements(
+					ref("ConfigMap", "valueFrom", "configMapKeyRef", "name"),
+					ref("Secret", "valueFrom", "secretKeyRef", "name"),
+				)),
+				lookup([]string{"envFrom"}, visitElements(
+					ref("ConfigMap", "configMapRef", "name"),
+					ref("Secret", "secretRef", "name"),
+				)),
+			)))
+		}
+	}
+
+	switch kind {
+	case "StatefulSet":
+		fns = append(fns, ref("Service", "spec", "serviceName"))
+
+	case "Ingress":
+		fns = append(fns,
+			ref("Service", "spec", "defaultBackend", "service", "name"),
+			lookup([]string{"spec", "rules"}, visitElements(
+				lookup([]string{"http", "paths"}, visitElements(ref("Service", "backend", "service", "name"))),
+			)),
+		)
+
+	case "HorizontalPodAutoscaler":
+		fns = append(fns, lookup([]string{"spec", "scaleTargetRef"}, t.setKindRef(namespace)))
+
+	case "RoleBinding", "ClusterRoleBinding":
+		fns = append(fns,
+			lookup([]string{"roleRef"}, t.setKindRef(namespace)),
+			lookup([]string{"subjects"}, visitElements(t.setSubject())),
+		)
+	}
+
+	return fns
+}
+
+// setRef returns a filter that renames the resource referenced by the field at the specified path.
+func (t *transformer) setRef(kind, namespace string, path ...string) yaml.Filter {
+	return yaml.FilterFunc(func(object *yaml.RNode) (*yaml.RNode, error) {
+		field, err := object.Pipe(yaml.Lookup(path...))
+		if err != nil || field == nil || field.YNode().Kind != yaml.ScalarNode {
+			return nil, err
+		}
+		field.YNode().Value = t.rename(kind, namespace, field.YNode().Value)
+		return object, nil
+	})
+}
+
+// setKindRef returns a filter that renames the resource referenced by an object containing `kind` and `name` fields.
+func (t *transformer) setKindRef(namespace string) yaml.Filter {
+	return yaml.FilterFunc(func(object *yaml.RNode) (*yaml.RNode, error) {
+		kind, _ := object.GetString("kind")
+		if kind == "ClusterRole" {
+			return object.Pipe(t.setRef(kind, "", "name"))
+		}
+		return object.Pipe(t.setRef(kind, namespace, "name"))
+	})
+}
+
+// setSubject returns a filter that updates the name and namespace of a role binding subject.
+func (t *transformer) setSubject() yaml.Filter {
+	return yaml.FilterFunc(func(object *yaml.RNode) (*yaml.RNode, error) {
+		if kind, _ := object.GetString("kind"); kind != "ServiceAccount" {
+			return object, nil
+		}
+
+		name, _ := object.GetString("name")
+		namespace, _ := object.GetString("namespace")
+		if !t.known[transformKey{kind: "ServiceAccount", namespace: namespace, name: name}] {
+			return object, nil
+		}
+
+		if err := object.PipeE(t.setRef("ServiceAccount", namespace, "name")); err != nil {
+			return nil, err
+		}
+		if t.Namespace != "" {
+			return object.Pipe(yaml.SetField("namespace", yaml.NewStringRNode(t.Namespace)))
+		}
+		return object, nil
+	})
+}
+
+// images returns the filters used to override container images.
+func (t *transformer) images(n *yaml.RNode) []yaml.Filter {
+	if len(t.Images) == 0 {
+		return nil
+	}
+
+	setImage := yaml.FilterFunc(func(object *yaml.RNode) (*yaml.RNode, error) {
+		image := object.Field("image")
+		if image == nil || image.Value.YNode().Kind != yaml.ScalarNode {
+			return object, nil
+		}
+		image.Value.YNode().Value = overrideImage(image.Value.YNode().Value, t.Images)
+		return object, nil
+	})
+
+	var fns []yaml.Filter
+	for _, p := range podTemplatePaths(n) {
+		for _, c := range []string{"containers", "initContainers"} {
+			fns = append(fns, lookup(subPath(p, "spec", c), visitElements(setImage)))
+		}
+	}
+	return fns
+}
+
+// overrideImage returns the image after applying the first matching override.
+func overrideImage(image string, overrides []string) string {
+	name := imageName(image)
+	for _, o := range overrides {
+		on, ref, ok := strings.Cut(o, "=")
+		if !ok {
+			on, ref = imageName(o), o
+		}
+		if on != name {
+			continue
+		}
+		if strings.HasPrefix(ref, ":") || strings.HasPrefix(ref, "@") {
+			return name + ref
+		}
+		return ref
+	}
+	return image
+}
+
+// imageName returns the image name without the tag or digest.
+func imageName(image string) string {
+	if i := strings.Index(image, "@"); i >= 0 {
+		image = image[:i]
+	}
+	if i := strings.LastIndex(image, ":"); i > strings.LastIndex(image, "/") {
+		image = image[:i]
+	}
+	return image
+}
+
+// renamable checks if resources of the supplied kind can have their names changed.
+func renamable(kind string) bool {
+	switch kind {
+	case "CustomResourceDefinition", "Namespace", "APIService":
+		return false
+	}
+	return true
+}
+
+// selectorPaths returns the paths to the label selectors of the node.
+func selectorPaths(n *yaml.RNode) [][]string {
+	switch n.GetKind() {
+	case "Service", "ReplicationController":
+		return [][]string{{"spec", "selector"}}
+	case "Deployment", "StatefulSet", "DaemonSet", "ReplicaSet", "PodDisruptionBudget":
+		return [][]string{{"spec", "selector", "matchLabels"}}
+	}
+	return nil
+}
+
+// lookup returns a filter which applies the supplied filters to the node at the specified path, if it exists.
+func lookup(path []string, fns ...yaml.Filter) yaml.Filter {
+	return yaml.FilterFunc(func(object *yaml.RNode) (*yaml.RNode, error) {
+		m, err := object.Pipe(yaml.Lookup(path...))
+		if err != nil || m == nil {
+			return nil, err
+		}
+		return object, apply(m, fns)
+	})
+}
+
+// setIfExists returns a filter that sets a string field on the map at the specified path, if it exists.
+func setIfExists(path []string, name, value string) yaml.Filter {
+	return lookup(path, yaml.SetField(name, yaml.NewStringRNode(value)))
+}
+
+// setField returns a filter that sets a string field on the map at the specified path, creating it if necessary.
+func setField(path []string, name, value string) yaml.Filter {
+	return yaml.FilterFunc(func(object *yaml.RNode) (*yaml.RNode, error) {
+		m, err := object.Pipe(yaml.LookupCreate(yaml.MappingNode, path...))
+		if err != nil {
+			return nil, err
+		}
+		return object, m.PipeE(yaml.SetField(name, yaml.NewStringRNode(value)))
+	})
+}
+
+// subPath returns a new path with the additional path elements.
+func subPath(path []string, elems ...string) []string {
+	return append(path[:len(path):len(path)], elems...)
+}
+
+// sortedKeys returns the keys of the map in order.
+func sortedKeys(m map[string]string) []string {
+	keys := make([]string, 0, len(m))
+	for k := range m {
+		keys = append(keys, k)
+	}
+	sort.Strings(keys)
+	return keys
+}
@@ -0,0 +1,189 @@
+/*
+Copyright 2023 GramLabs, Inc.
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package filters
+
+import (
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+	"sigs.k8s.io/kustomize/kyaml/kio"
+)
+
+func TestTransformFilter_Filter(t *testing.T) {
+	resources := `apiVersion: apps/v1
+kind: Deployment
+metadata:
+  name: web
+  namespace: default
+spec:
+  selector:
+    matchLabels:
+      app: web
+  template:
+    metadata:
+      labels:
+        app: web
+    spec:
+      serviceAccountName: web
+      containers:
+      - name: web
+        image: nginx:1.24
+        envFrom:
+        - configMapRef:
+            name: web-config
+        - secretRef:
+            name: external
+---
+apiVersion: v1
+kind: ConfigMap
+metadata:
+  name: web-config
+  namespace: default
+---
+apiVersion: v1
+kind: ServiceAccount
+metadata:
+  name: web
+  namespace: default
+---
+apiVersion: rbac.authorization.k8s.io/v1
+kind: ClusterRoleBinding
+metadata:
+  name: web
+roleRef:
+  apiGroup: rbac.authorization.k8s.io
+  kind: ClusterRole
+  name: view
+subjects:
+- kind: ServiceAccount
+  name: web
+  namespace: default
+`
+
+	cases := []struct {
+		desc     string
+		filter   TransformFilter
+		expected string
+	}{
+		{
+			desc:     "empty",
+			expected: resources,
+		},
+		{
+			desc: "all",
+			filter: TransformFilter{
+				Namespace:    "prod",
+				NamePrefix:   "p-",
+				CommonLabels: map[string]string{"team": "a"},
+				Images:       []string{"nginx=:1.25"},
+			},
+			expected: `apiVersion: apps/v1
+kind: Deployment
+metadata:
+  name: p-web
+  namespace: prod
+  labels:
+    team: a
+spec:
+  selector:
+    matchLabels:
+      app: web
+      team: a
+  template:
+    metadata:
+      labels:
+        app: web
+        team: a
+    spec:
+      serviceAccountName: p-web
+      containers:
+      - name: web
+        image: nginx:1.25
+        envFrom:
+        - configMapRef:
+            name: p-web-config
+        - secretRef:
+            name: external
+---
+apiVersion: v1
+kind: ConfigMap
+metadata:
+  name: p-web-config
+  namespace: prod
+  labels:
+    team: a
+---
+apiVersion: v1
+kind: ServiceAccount
+metadata:
+  name: p-web
+  namespace: prod
+  labels:
+    team: a
+---
+apiVersion: rbac.authorization.k8s.io/v1
+kind: ClusterRoleBinding
+metadata:
+  name: p-web
+  labels:
+    team: a
+roleRef:
+  apiGroup: rbac.authorization.k8s.io
+  kind: ClusterRole
+  name: view
+subjects:
+- kind: ServiceAccount
+  name: p-web
+  namespace: prod
+`,
+		},
+	}
+	for _, c := range cases {
+		t.Run(c.desc, func(t *testing.T) {
+			nodes, err := kio.FromBytes([]byte(resources))
+			require.NoError(t, err)
+			actual, err := c.filter.Filter(nodes)
+			if assert.NoError(t, err) {
+				out, err := kio.StringAll(actual)
+				if assert.NoError(t, err) {
+					assert.Equal(t, c.expected, out)
+				}
+			}
+		})
+	}
+}
+
+func TestOverrideImage(t *testing.T) {
+	cases := []struct {
+		image     string
+		overrides []string
+		expected  string
+	}{
+		{image: "nginx", overrides: []string{"nginx=nginx:1.25"}, expected: "nginx:1.25"},
+		{image: "nginx:1.24", overrides: []string{"nginx=:1.25"}, expected: "nginx:1.25"},
+		{image: "nginx:1.24", overrides: []string{"nginx=@sha256:abc"}, expected: "nginx@sha256:abc"},
+		{image: "localhost:5000/app:v1", overrides: []string{"localhost:5000/app=registry.example.com/app:v2"}, expected: "registry.example.com/app:v2"},
+		{image: "nginx:1.24", overrides: []string{"nginx:1.25"}, expected: "nginx:1.25"},
+		{image: "redis:7", overrides: []string{"nginx=:1.25"}, expected: "redis:7"},
+	}
+	for _, c := range cases {
+		t.Run(c.image, func(t *testing.T) {
+			assert.Equal(t, c.expected, overrideImage(c.image, c.overrides))
+		})
+	}
+}
@@ -0,0 +1,481 @@
+/*
+Copyright 2023 GramLabs, Inc.
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package filters
+
+import (
+	"encoding/json"
+	"fmt"
+	"path"
+	"sort"
+	"strings"
+	"sync"
+
+	openapi_v2 "github.com/google/gnostic/openapiv2"
+	"google.golang.org/protobuf/proto"
+	"k8s.io/kube-openapi/pkg/validation/spec"
+	"sigs.k8s.io/kustomize/kyaml/kio"
+	"sigs.k8s.io/kustomize/kyaml/kio/kioutil"
+	"sigs.k8s.io/kustomize/kyaml/openapi/kubernetesapi"
+	"sigs.k8s.io/kustomize/kyaml/yaml"
+)
+
+// ValidationFilter checks resources against the OpenAPI schema for their type.
+// Schemas for built-in types are bundled, custom resources are checked using
+// the CustomResourceDefinitions in the resource list or supplied explicitly.
+// Resources whose type has no known schema are not checked.
+type ValidationFilter struct {
+	// Flag indicating if this filter should act as a pass-through.
+	Enabled bool
+	// The Kubernetes version of the bundled schemas, defaults to the latest bundled version.
+	KubernetesVersion string
+	// Additional CustomResourceDefinitions used to check custom resources.
+	CustomResourceDefinitions []*yaml.RNode
+}
+
+// Filter returns the nodes unchanged or a ValidationError describing every
+// field which does not match the schema.
+func (f *ValidationFilter) Filter(nodes []*yaml.RNode) ([]*yaml.RNode, error) {
+	if !f.Enabled {
+		return nodes, nil
+	}
+
+	builtIn, err := bundledSchemas(f.KubernetesVersion)
+	if err != nil {
+		return nil, err
+	}
+
+	custom := make(map[yaml.TypeMeta]*spec.Schema)
+	for _, crds := range [][]*yaml.RNode{f.CustomResourceDefinitions, nodes} {
+		for _, crd := range crds {
+			if err := addCustomResourceSchemas(custom, crd); err != nil {
+				return nil, err
+			}
+		}
+	}
+
+	result := &ValidationError{}
+	for _, node := range nodes {
+		md, err := node.GetMeta()
+		if err != nil {
+			continue
+		}
+
+		s := custom[md.TypeMeta]
+		if s == nil {
+			s = builtIn.types[md.TypeMeta]
+		}
+		if s == nil {
+			continue
+		}
+
+		v := &validator{
+			definitions: builtIn.definitions,
+			id:          md.GetIdentifier(),
+			source:      sourceFile(node),
+		}
+		v.validate("", node.YNode(), s)
+		result.Errors = append(result.Errors, v.errors...)
+	}
+
+	if len(result.Errors) > 0 {
+		return nil, result
+	}
+	return nodes, nil
+}
+
+// Collect returns a filter that records the CustomResourceDefinitions passing
+// through it so custom resources can still be checked if the definitions are
+// removed from the resource list before validation.
+func (f *ValidationFilter) Collect() kio.Filter {
+	return kio.FilterFunc(func(nodes []*yaml.RNode) ([]*yaml.RNode, error) {
+		if !f.Enabled {
+			return nodes, nil
+		}
+
+		crds := f.CustomResourceDefinitions[:len(f.CustomResourceDefinitions):len(f.CustomResourceDefinitions)]
+		for _, n := range nodes {
+			if n.GetKind() == "CustomResourceDefinition" {
+				crds = append(crds, n)
+			}
+		}
+		f.CustomResourceDefinitions = crds
+		return nodes, nil
+	})
+}
+
+// FieldError describes a single field which does not match the schema.
+type FieldError struct {
+	// The resource containing the field.
+	yaml.ResourceIdentifier
+	// The file the resource was read from, if known.
+	Source string
+	// The path to the field.
+	Path string
+	// The description of the problem.
+	Message string
+}
+
+func (e *FieldError) Error() string {
+	name := e.Name
+	if e.Namespace != "" {
+		name = e.Namespace + "/" + name
+	}
+	msg := fmt.Sprintf("%s %s %s: %s", e.APIVersion, e.Kind, name, e.Message)
+	if e.Path != "" {
+		msg = fmt.Sprintf("%s %s %s: %s: %s", e.APIVersion, e.Kind, name, e.Path, e.Message)
+	}
+	if e.Source != "" {
+		msg = e.Source + ": " + msg
+	}
+	return msg
+}
+
+// ValidationError is returned when resources do not match their schemas.
+type ValidationError struct {
+	Errors []FieldError
+}
+
+func (e *ValidationError) Error() string {
+	msgs := make([]string, 0, len(e.Errors)+1)
+	msgs = append(msgs, fmt.Sprintf("validation failed with %d error(s)", len(e.Errors)))
+	for i := range e.Errors {
+		msgs = append(msgs, e.Errors[i].Error())
+	}
+	return strings.Join(msgs, "\n  ")
+}
+
+// KubernetesVersions returns the Kubernetes versions of the bundled schemas.
+func KubernetesVersions() []string {
+	versions := make([]string, 0, len(kubernetesapi.OpenAPIMustAsset))
+	for v := range kubernetesapi.OpenAPIMustAsset {
+		versions = append(versions, v)
+	}
+	sort.Strings(versions)
+	return versions
+}
+
+// schemaSet is the index of the schemas for a specific Kubernetes version.
+type schemaSet struct {
+	definitions spec.Definitions
+	types       map[yaml.TypeMeta]*spec.Schema
+}
+
+var (
+	bundledMu  sync.Mutex
+	bundledSet = make(map[string]*schemaSet)
+)
+
+// bundledSchemas returns the (lazily parsed) schemas for a bundled Kubernetes version.
+func bundledSchemas(version string) (*schemaSet, error) {
+	if version == "" {
+		version = kubernetesapi.DefaultOpenAPI
+	}
+	version = "v" + strings.TrimPrefix(version, "v")
+
+	asset, ok := kubernetesapi.OpenAPIMustAsset[version]
+	if !ok {
+		return nil, fmt.Errorf("no schemas for Kubernetes %s, available versions: %s", version, strings.Join(KubernetesVersions(), ", "))
+	}
+
+	bundledMu.Lock()
+	defer bundledMu.Unlock()
+	if set, ok := bundledSet[version]; ok {
+		return set, nil
+	}
+
+	doc := &openapi_v2.Document{}
+	if err := proto.Unmarshal(asset(path.Join("kubernetesapi", strings.ReplaceAll(version, ".", "_"), "swagger.pb")), doc); err != nil {
+		return nil, err
+	}
+	swagger := &spec.Swagger{}
+	if _, err := swagger.FromGnostic(doc); err != nil {
+		return nil, err
+	}
+
+	set := &schemaSet{
+		definitions: swagger.Definitions,
+		types:       make(map[yaml.TypeMeta]*spec.Schema),
+	}
+	for name := range swagger.Definitions {
+		s := swagger.Definitions[name]
+		gvks, _ := s.Extensions["x-kubernetes-group-version-kind"].([]interface{})
+		for _, gvk := range gvks {
+			m, _ := gvk.(map[string]interface{})
+			group, _ := m["group"].(string)
+			version, _ := m["version"].(string)
+			kind, _ := m["kind"].(string)
+			set.types[yaml.TypeMeta{APIVersion: path.Join(group, version), Kind: kind}] = &s
+		}
+	}
+
+	bundledSet[version] = set
+	return set, nil
+}
+
+// addCustomResourceSchemas indexes the schemas of a CustomResourceDefinition.
+func addCustomResourceSchemas(types map[yaml.TypeMeta]*spec.Schema, node *yaml.RNode) error {
+	if node.GetKind() != "CustomResourceDefinition" || !strings.HasPrefix(node.GetApiVersion(), "apiextensions.k8s.io/") {
+		return nil
+	}
+
+	group, _ := node.GetString("spec.group")
+	kind, _ := node.GetString("spec.names.kind")
+
+	// The v1beta1 API allowed a single schema for every version
+	shared, err := node.Pipe(yaml.Lookup("spec", "validation", "openAPIV3Schema"))
+	if err != nil {
+		return err
+	}
+
+	var versions []string
+	if v, _ := node.GetString("spec.version"); v != "" {
+		versions = append(versions, v)
+	}
+	schemas := make(map[string]*yaml.RNode)
+	if err := node.PipeE(
+		yaml.Lookup("spec", "versions"),
+		yaml.FilterFunc(func(object *yaml.RNode) (*yaml.RNode, error) {
+			return nil, object.VisitElements(func(node *yaml.RNode) error {
+				name, _ := node.GetString("name")
+				versions = append(versions, name)
+				s, err := node.Pipe(yaml.Lookup("schema", "openAPIV3Schema"))
+				schemas[name] = s
+				return err
+			})
+		})); err != nil {
+		return err
+	}
+
+	for _, v := range versions {
+		sn := schemas[v]
+		if sn == nil {
+			sn = shared
+		}
+		if sn == nil {
+			continue
+		}
+
+		data, err := sn.MarshalJSON()
+		if err != nil {
+			return err
+		}
+		s := &spec.Schema{}
+		if err := json.Unmarshal(data, s); err != nil {
+			return fmt.Errorf("invalid schema for %s/%s %s: %w", group, v, kind, err)
+		}
+		types[yaml.TypeMeta{APIVersion: group + "/" + v, Kind: kind}] = s
+	}
+	return nil
+}
+
+// sourceFile returns the file a node was read from, if known.
+func sourceFile(node *yaml.RNode) string {
+	annotations := node.GetAnnotations()
+	for _, a := range []string{kioutil.PathAnnotation, kioutil.LegacyPathAnnotation} {
+		if p := annotations[a]; p != "" {
+			return p
+		}
+	}
+
+	// Fall back to the innermost origin recorded in the provenance
+	origins, _ := GetProvenance(node)
+	for i := len(origins) - 1; i >= 0; i-- {
+		if origins[i].File != "" {
+			return origins[i].File
+		}
+		if origins[i].Source != "" {
+			return origins[i].Source
+		}
+	}
+	return ""
+}
+
+// validator walks a YAML node checking it against a schema.
+type validator struct {
+	definitions spec.Definitions
+	id          yaml.ResourceIdentifier
+	source      string
+	errors      []FieldError
+}
+
+func (v *validator) errorf(path, format string, args ...interface{}) {
+	v.errors = append(v.errors, FieldError{
+		ResourceIdentifier: v.id,
+		Source:             v.source,
+		Path:               path,
+		Message:            fmt.Sprintf(format, args...),
+	})
+}
+
+// resolve follows schema references.
+func (v *validator) resolve(s *spec.Schema) *spec.Schema {
+	for s != nil && s.Ref.String() != "" {
+		d, ok := v.definitions[strings.TrimPrefix(s.Ref.String(), "#/definitions/")]
+		if !ok {
+			return nil
+		}
+		s = &d
+	}
+	return s
+}
+
+func (v *validator) validate(path string, node *yaml.Node, s *spec.Schema) {
+	// Older bundled schemas do not include a format for Quantity, only the definition name identifies it
+	quantity := s != nil && strings.HasSuffix(s.Ref.String(), "/io.k8s.apimachinery.pkg.api.resource.Quantity")
+	s = v.resolve(s)
+	if s == nil || node == nil {
+		return
+	}
+	if node.Kind == yaml.AliasNode {
+		node = node.Alias
+	}
+
+	tag := node.ShortTag()
+	if tag == yaml.NodeTagNull {
+		return
+	}
+
+	// The bundled schemas use formats instead of the extension for IntOrString and Quantity
+	if extension(s, "x-kubernetes-int-or-string") || s.Format == "int-or-string" {
+		if tag != yaml.NodeTagInt && tag != yaml.NodeTagString {
+			v.errorf(path, "expected integer or string, found %s", describe(node))
+		}
+		return
+	}
+	if s.Format == "quantity" || quantity {
+		if tag != yaml.NodeTagInt && tag != yaml.NodeTagFloat && tag != yaml.NodeTagString {
+			v.errorf(path, "expected quantity, found %s", describe(node))
+		}
+		return
+	}
+
+	switch {
+	case s.Type.Contains("object") || (len(s.Type) == 0 && (len(s.Properties) > 0 || s.AdditionalProperties != nil)):
+		if node.Kind != yaml.MappingNode {
+			v.errorf(path, "expected object, found %s", describe(node))
+			return
+		}
+		v.validateObject(path, node, s)
+
+	case s.Type.Contains("array"):
+		if node.Kind != yaml.SequenceNode {
+			v.errorf(path, "expected array, found %s", describe(node))
+			return
+		}
+		if s.Items != nil && s.Items.Schema != nil {
+			for i, e := range node.Content {
+				v.validate(fmt.Sprintf("%s[%d]", path, i), e, s.Items.Schema)
+			}
+		}
+
+	case s.Type.Contains("string"):
+		if tag != yaml.NodeTagString && tag != "!!timestamp" && tag != "!!binary" {
+			v.errorf(path, "expected string, found %s", describe(node))
+		}
+
+	case s.Type.Contains("integer"):
+		if tag != yaml.NodeTagInt {
+			v.errorf(path, "expected integer, found %s", describe(node))
+		}
+
+	case s.Type.Contains("number"):
+		if tag != yaml.NodeTagInt && tag != yaml.NodeTagFloat {
+			v.errorf(path, "expected number, found %s", describe(node))
+		}
+
+	case s.Type.Contains("boolean"):
+		if tag != yaml.NodeTagBool {
+			v.errorf(path, "expected boolean, found %s", describe(node))
+		}
+	}
+}
+
+func (v *validator) validateObject(path string, node *yaml.Node, s *spec.Schema) {
+	embedded := path == "" || extension(s, "x-kubernetes-embedded-resource")
+	preserve := extension(s, "x-kubernetes-preserve-unknown-fields")
+
+	fields := make(map[string]struct{}, len(node.Content)/2)
+	for i := 0; i+1 < len(node.Content); i += 2 {
+		key, value := node.Content[i].Value, node.Content[i+1]
+		fieldPath := key
+		if path != "" {
+			fieldPath = path + "." + key
+		}
+		fields[key] = struct{}{}
+
+		if ps, ok := s.Properties[key]; ok {
+			v.validate(fieldPath, value, &ps)
+			continue
+		}
+
+		if s.AdditionalProperties != nil {
+			if s.AdditionalProperties.Schema != nil {
+				v.validate(fieldPath, value, s.AdditionalProperties.Schema)
+			} else if !s.AdditionalProperties.Allows {
+				v.errorf(fieldPath, "unknown field")
+			}
+			continue
+		}
+
+		// Resources always allow type and object metadata, even if the schema omits them
+		if embedded && (key == "apiVersion" || key == "kind" || key == "metadata") {
+			continue
+		}
+
+		if !preserve && len(s.Properties) > 0 {
+			v.errorf(fieldPath, "unknown field")
+		}
+	}
+
+	for _, r := range s.Required {
+		if _, ok := fields[r]; !ok {
+			fieldPath := r
+			if path != "" {
+				fieldPath = path + "." + r
+			}
+			v.errorf(fieldPath, "missing required field")
+		}
+	}
+}
+
+// extension returns the boolean value of a vendor extension.
+func extension(s *spec.Schema, name string) bool {
+	b, _ := s.Extensions[name].(bool)
+	return b
+}
+
+// describe returns the type of node for error messages.
+func describe(node *yaml.Node) string {
+	switch node.Kind {
+	case yaml.MappingNode:
+		return "object"
+	case yaml.SequenceNode:
+		return "array"
+	}
+
+	switch node.ShortTag() {
+	case yaml.NodeTagString:
+		return "string"
+	case yaml.NodeTagInt:
+		return "integer"
+	case yaml.NodeTagFloat:
+		return "number"
+	case yaml.NodeTagBool:
+		return "boolean"
+	}
+	return node.ShortTag()
+}
@@ -0,0 +1,339 @@
+/*
+Copyright 2023 GramLabs, Inc.
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package filters
+
+import (
+	"strings"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+	"sigs.k8s.io/kustomize/kyaml/kio"
+	"sigs.k8s.io/kustomize/kyaml/yaml"
+)
+
+func TestValidationFilter_Filter(t *testing.T) {
+	crd := `apiVersion: apiextensions.k8s.io/v1
+kind: CustomResourceDefinition
+metadata:
+  name: widgets.example.com
+spec:
+  group: example.com
+  names:
+    kind: Widget
+    plural: widgets
+  scope: Namespaced
+  versions:
+  - name: v1
+    served: true
+    storage: true
+    schema:
+      openAPIV3Schema:
+        type: object
+        properties:
+          spec:
+            type: object
+            required: [size]
+            properties:
+              size:
+                type: integer
+              extra:
+                type: object
+                x-kubernetes-preserve-unknown-fields: true
+`
+
+	cases := []struct {
+		desc     string
+		filter   ValidationFilter
+		input    string
+		expected []string
+	}{
+		{
+			desc:   "disabled",
+			filter: ValidationFilter{},
+			input: `apiVersion: apps/v1
+kind: Deployment
+metadata:
+  name: test
+spec:
+  replica: 1
+`,
+		},
+		{
+			desc:   "valid deployment",
+			filter: ValidationFilter{Enabled: true},
+			input: `apiVersion: apps/v1
+kind: Deployment
+metadata:
+  name: test
+  labels:
+    app: test
+spec:
+  replicas: 1
+  selector:
+    matchLabels:
+      app: test
+  template:
+    metadata:
+      labels:
+        app: test
+    spec:
+      containers:
+      - name: test
+        image: nginx
+        ports:
+        - containerPort: 80
+        resources:
+          limits:
+            cpu: 100m
+            memory: 1Gi
+`,
+		},
+		{
+			desc:   "invalid deployment",
+			filter: ValidationFilter{Enabled: true},
+			input: `apiVersion: apps/v1
+kind: Deployment
+metadata:
+  name: test
+  namespace: default
+  annotations:
+    config.kubernetes.io/path: deployment.yaml
+spec:
+  replica: 1
+  selector:
+    matchLabels:
+      app: test
+  template:
+    spec:
+      containers:
+      - image: nginx
+        imagePullPolicy: true
+`,
+			expected: []string{
+				"deployment.yaml: apps/v1 Deployment default/test: spec.replica: unknown field",
+				"deployment.yaml: apps/v1 Deployment default/test: spec.template.spec.containers[0].imagePullPolicy: expected string, found boolean",
+				"deployment.yaml: apps/v1 Deployment default/test: spec.template.spec.containers[0].name: missing required field",
+			},
+		},
+		{
+			desc:   "int or string and quantity formats",
+			filter: ValidationFilter{Enabled: true},
+			input: `apiVersion: v1
+kind: Service
+metadata:
+  name: test
+spec:
+  ports:
+  - port: 80
+    targetPort: 8080
+  - port: 443
+    targetPort: https
+---
+apiVersion: apps/v1
+kind: Deployment
+metadata:
+  name: test
+spec:
+  selector:
+    matchLabels:
+      app: test
+  strategy:
+    rollingUpdate:
+      maxSurge: 1
+      maxUnavailable: 25%
+  template:
+    metadata:
+      labels:
+        app: test
+    spec:
+      containers:
+      - name: test
+        image: nginx
+        resources:
+          limits:
+            cpu: 1
+            memory: 1Gi
+          requests:
+            cpu: 0.5
+`,
+		},
+		{
+			desc:   "invalid int or string and quantity formats",
+			filter: ValidationFilter{Enabled: true},
+			input: `apiVersion: v1
+kind: Service
+metadata:
+  name: test
+spec:
+  ports:
+  - port: 80
+    targetPort: true
+---
+apiVersion: v1
+kind: Pod
+metadata:
+  name: test
+spec:
+  containers:
+  - name: test
+    resources:
+      limits:
+        cpu: [1]
+`,
+			expected: []string{
+				"v1 Service test: spec.ports[0].targetPort: expected integer or string, found boolean",
+				"v1 Pod test: spec.containers[0].resources.limits.cpu: expected quantity, found array",
+			},
+		},
+		{
+			desc:   "unknown type",
+			filter: ValidationFilter{Enabled: true},
+			input: `apiVersion: example.com/v1
+kind: Gadget
+metadata:
+  name: test
+spec:
+  anything: goes
+`,
+		},
+		{
+			desc:   "provenance source",
+			filter: ValidationFilter{Enabled: true},
+			input: `apiVersion: v1
+kind: ConfigMap
+metadata:
+  name: test
+  annotations:
+    ` + ProvenanceAnnotation + `: '[{"kind":"Helm","source":"https://example.com/charts/test"},{"kind":"File","file":"templates/configmap.yaml"}]'
+datas: {}
+`,
+			expected: []string{
+				"templates/configmap.yaml: v1 ConfigMap test: datas: unknown field",
+			},
+		},
+		{
+			desc:   "custom resource definition in resources",
+			filter: ValidationFilter{Enabled: true},
+			input: crd + `---
+apiVersion: example.com/v1
+kind: Widget
+metadata:
+  name: test
+spec:
+  size: large
+  color: red
+  extra:
+    anything: goes
+`,
+			expected: []string{
+				"example.com/v1 Widget test: spec.size: expected integer, found string",
+				"example.com/v1 Widget test: spec.color: unknown field",
+			},
+		},
+		{
+			desc: "custom resource definition supplied",
+			filter: ValidationFilter{
+				Enabled:                   true,
+				CustomResourceDefinitions: []*yaml.RNode{yaml.MustParse(crd)},
+			},
+			input: `apiVersion: example.com/v1
+kind: Widget
+metadata:
+  name: test
+spec: {}
+`,
+			expected: []string{
+				"example.com/v1 Widget test: spec.size: missing required field",
+			},
+		},
+	}
+	for _, c := range cases {
+		t.Run(c.desc, func(t *testing.T) {
+			nodes, err := kio.FromBytes([]byte(c.input))
+			require.NoError(t, err)
+
+			actual, err := c.filter.Filter(nodes)
+			if len(c.expected) == 0 {
+				assert.NoError(t, err)
+				assert.Len(t, actual, len(nodes))
+				return
+			}
+
+			var verr *ValidationError
+			if assert.ErrorAs(t, err, &verr) {
+				var messages []string
+				for i := range verr.Errors {
+					messages = append(messages, verr.Errors[i].Error())
+				}
+				assert.Equal(t, c.expected, messages)
+			}
+		})
+	}
+}
+
+func TestValidationFilter_KubernetesVersion(t *testing.T) {
+	f := &ValidationFilter{Enabled: true, KubernetesVersion: "0.1.0"}
+	_, err := f.Filter(nil)
+	assert.EqualError(t, err, "no schemas for Kubernetes v0.1.0, available versions: "+strings.Join(KubernetesVersions(), ", "))
+}
+
+func TestValidationFilter_Collect(t *testing.T) {
+	nodes, err := kio.FromBytes([]byte(`apiVersion: apiextensions.k8s.io/v1
+kind: CustomResourceDefinition
+metadata:
+  name: widgets.example.com
+spec:
+  group: example.com
+  names:
+    kind: Widget
+    plural: widgets
+  scope: Namespaced
+  versions:
+  - name: v1
+    served: true
+    storage: true
+    schema:
+      openAPIV3Schema:
+        type: object
+        properties:
+          spec:
+            type: object
+            properties:
+              size:
+                type: integer
+---
+apiVersion: example.com/v1
+kind: Widget
+metadata:
+  name: test
+spec:
+  size: large
+`))
+	require.NoError(t, err)
+
+	f := &ValidationFilter{Enabled: true}
+	_, err = f.Collect().Filter(nodes)
+	require.NoError(t, err)
+
+	// The definition is no longer in the resource list when validation happens
+	_, err = f.Filter(nodes[1:])
+	var verr *ValidationError
+	if assert.ErrorAs(t, err, &verr) && assert.Len(t, verr.Errors, 1) {
+		assert.Equal(t, "example.com/v1 Widget test: spec.size: expected integer, found string", verr.Errors[0].Error())
+	}
+}
@@ -0,0 +1,105 @@
+/*
+Copyright 2023 GramLabs, Inc.
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package konjure
+
+import (
+	"encoding/json"
+	"fmt"
+	"sort"
+	"strings"
+
+	konjurev1beta2 "github.com/thestormforge/konjure/pkg/api/core/v1beta2"
+)
+
+// ArgoCDParameter is a Config Management Plugin parameter supplied by Argo CD.
+type ArgoCDParameter struct {
+	// The name of the parameter.
+	Name string `json:"name"`
+	// The value of a string parameter.
+	String *string `json:"string,omitempty"`
+	// The values of an array parameter.
+	Array []string `json:"array,omitempty"`
+	// The values of a map parameter.
+	Map map[string]string `json:"map,omitempty"`
+}
+
+// ConfigureArgoCD configures the filter from the environment Argo CD supplies to
+// a Config Management Plugin. The application name and namespace are used as the
+// default Helm release, plugin parameters become Helm values, and the `ARGOCD_APP_*`
+// and `ARGOCD_ENV_*` variables become Jsonnet external variables (the latter
+// without the prefix).
+func (f *Filter) ConfigureArgoCD(environ []string) error {
+	for _, kv := range environ {
+		k, v, _ := strings.Cut(kv, "=")
+		switch {
+		case k == "ARGOCD_APP_PARAMETERS":
+			var params []ArgoCDParameter
+			if err := json.Unmarshal([]byte(v), &params); err != nil {
+				return fmt.Errorf("invalid %s: %w", k, err)
+			}
+			f.HelmValues = append(f.HelmValues, argoCDHelmValues(params)...)
+
+		case strings.HasPrefix(k, "ARGOCD_APP_"):
+			switch k {
+			case "ARGOCD_APP_NAME":
+				f.HelmReleaseName = v
+			case "ARGOCD_APP_NAMESPACE":
+				f.HelmReleaseNamespace = v
+			}
+			f.JsonnetExternalVariables = append(f.JsonnetExternalVariables, jsonnetString(k, v))
+
+		case strings.HasPrefix(k, "ARGOCD_ENV_"):
+			f.JsonnetExternalVariables = append(f.JsonnetExternalVariables, jsonnetString(strings.TrimPrefix(k, "ARGOCD_ENV_"), v))
+		}
+	}
+
+	return nil
+}
+
+// argoCDHelmValues converts plugin parameters into Helm values.
+func argoCDHelmValues(params []ArgoCDParameter) []konjurev1beta2.HelmValue {
+	var values []konjurev1beta2.HelmValue
+	for _, p := range params {
+		switch {
+		case p.String != nil:
+			values = append(values, konjurev1beta2.HelmValue{Name: p.Name, Value: *p.String})
+
+		case p.Array != nil:
+			values = append(values, konjurev1beta2.HelmValue{Name: p.Name, Value: "{" + strings.Join(p.Array, ",") + "}"})
+
+		case p.Map != nil:
+			keys := make([]string, 0, len(p.Map))
+			for k := range p.Map {
+				keys = append(keys, k)
+			}
+			sort.Strings(keys)
+			for _, k := range keys {
+				values = append(values, konjurev1beta2.HelmValue{Name: p.Name + "." + k, Value: p.Map[k]})
+			}
+		}
+	}
+	return values
+}
+
+// jsonnetString returns an external variable for a string value. Empty strings
+// must be supplied as code or they would be ignored.
+func jsonnetString(name, value string) konjurev1beta2.JsonnetParameter {
+	if value == "" {
+		return konjurev1beta2.JsonnetParameter{Name: name, Code: `""`}
+	}
+	return konjurev1beta2.JsonnetParameter{Name: name, String: value}
+}
@@ -0,0 +1,61 @@
+/*
+Copyright 2023 GramLabs, Inc.
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package konjure
+
+import (
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	konjurev1beta2 "github.com/thestormforge/konjure/pkg/api/core/v1beta2"
+)
+
+func TestFilter_ConfigureArgoCD(t *testing.T) {
+	f := &Filter{Depth: 1}
+	err := f.ConfigureArgoCD([]string{
+		"HOME=/home/argocd",
+		"ARGOCD_APP_NAME=guestbook",
+		"ARGOCD_APP_NAMESPACE=default",
+		"ARGOCD_ENV_TIER=frontend",
+		"ARGOCD_ENV_EMPTY=",
+		`ARGOCD_APP_PARAMETERS=[{"name":"image.tag","string":"v1"},{"name":"args","array":["a","b"]},{"name":"labels","map":{"team":"x","env":"dev"}}]`,
+	})
+	if !assert.NoError(t, err) {
+		return
+	}
+
+	assert.Equal(t, "guestbook", f.HelmReleaseName)
+	assert.Equal(t, "default", f.HelmReleaseNamespace)
+	assert.Equal(t, []konjurev1beta2.HelmValue{
+		{Name: "image.tag", Value: "v1"},
+		{Name: "args", Value: "{a,b}"},
+		{Name: "labels.env", Value: "dev"},
+		{Name: "labels.team", Value: "x"},
+	}, f.HelmValues)
+
+	// The variables are available to Jsonnet programs
+	nodes, err := Resources{{Jsonnet: &konjurev1beta2.Jsonnet{
+		Code: `{apiVersion: "v1", kind: "ConfigMap", metadata: {name: std.extVar("ARGOCD_APP_NAME")}, data: {tier: std.extVar("TIER"), empty: std.extVar("EMPTY")}}`,
+	}}}.Read()
+	if !assert.NoError(t, err) {
+		return
+	}
+	nodes, err = f.Filter(nodes)
+	if assert.NoError(t, err) && assert.Len(t, nodes, 1) {
+		assert.Equal(t, "guestbook", nodes[0].GetName())
+		assert.Equal(t, map[string]string{"tier": "frontend", "empty": ""}, nodes[0].GetDataMap())
+	}
+}
@@ -0,0 +1,423 @@
+/*
+Copyright 2023 GramLabs, Inc.
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package konjure
+
+import (
+	"encoding/json"
+	"fmt"
+	"io"
+	"path"
+	"reflect"
+	"sort"
+	"strings"
+
+	"github.com/pmezard/go-difflib/difflib"
+	"github.com/thestormforge/konjure/internal/readers"
+	"sigs.k8s.io/kustomize/kyaml/kio/filters"
+	"sigs.k8s.io/kustomize/kyaml/kio/kioutil"
+	"sigs.k8s.io/kustomize/kyaml/yaml"
+)
+
+// DiffType describes how a resource changed.
+type DiffType string
+
+const (
+	// DiffAdded indicates the resource only exists after the change.
+	DiffAdded DiffType = "added"
+	// DiffRemoved indicates the resource only exists before the change.
+	DiffRemoved DiffType = "removed"
+	// DiffChanged indicates the fields of the resource changed.
+	DiffChanged DiffType = "changed"
+)
+
+// ResourceDiff is the difference between two versions of a resource.
+type ResourceDiff struct {
+	yaml.ResourceIdentifier
+	// How the resource changed.
+	Type DiffType `json:"type"`
+	// The individual field changes, only present for changed resources.
+	Fields []FieldDiff `json:"fields,omitempty"`
+
+	before, after interface{}
+}
+
+// FieldDiff is the difference between two versions of a single field.
+type FieldDiff struct {
+	// The path to the field (e.g. `spec.template.spec.containers[name=app].image`).
+	Path string `json:"path"`
+	// The value before the change, nil if the field was added.
+	Before interface{} `json:"before,omitempty"`
+	// The value after the change, nil if the field was removed.
+	After interface{} `json:"after,omitempty"`
+}
+
+// listKeys are the fields used to match list elements, in order of preference.
+var listKeys = []string{"name", "mountPath", "containerPort", "port"}
+
+// readerAnnotations are the annotations which are not considered part of the resource.
+var readerAnnotations = []string{
+	readers.ProvenanceAnnotation,
+	kioutil.LegacyPathAnnotation,
+	kioutil.LegacyIndexAnnotation,
+	kioutil.LegacyIdAnnotation,
+	filters.FmtAnnotation,
+}
+
+// Diff compares two lists of resources, matching them by their resource identifier.
+// Resources are compared semantically so field order and formatting are ignored.
+func Diff(before, after []*yaml.RNode) ([]ResourceDiff, error) {
+	b, err := indexResources(before)
+	if err != nil {
+		return nil, err
+	}
+	a, err := indexResources(after)
+	if err != nil {
+		return nil, err
+	}
+
+	var result []ResourceDiff
+	for id, bv := range b {
+		av, ok := a[id]
+		if !ok {
+			result = append(result, ResourceDiff{ResourceIdentifier: id, Type: DiffRemoved, before: bv})
+			continue
+		}
+
+		if fields := diffValues("", bv, av, nil); len(fields) > 0 {
+			result = append(result, ResourceDiff{ResourceIdentifier: id, Type: DiffChanged, Fields: fields, before: bv, after: av})
+		}
+	}
+	for id, av := range a {
+		if _, ok := b[id]; !ok {
+			result = append(result, ResourceDiff{ResourceIdentifier: id, Type: DiffAdded, after: av})
+		}
+	}
+
+	sort.Slice(result, func(i, j int) bool {
+		return resourceKey(result[i].ResourceIdentifier) < resourceKey(result[j].ResourceIdentifier)
+	})
+	return result, nil
+}
+
+// WriteDiff writes the differences using the specified format (text, unified, or json).
+func WriteDiff(w io.Writer, format string, diffs []ResourceDiff) error {
+	switch strings.ToLower(format) {
+	case "text", "":
+		var lines []string
+		for _, d := range diffs {
+			lines = append(lines, diffSymbol(d.Type)+" "+resourceKey(d.ResourceIdentifier))
+			for _, f := range d.Fields {
+				switch {
+				case f.Before == nil:
+					lines = append(lines, fmt.Sprintf("    + %s: %s", f.Path, formatValue(f.After)))
+				case f.After == nil:
+					lines = append(lines, fmt.Sprintf("    - %s: %s", f.Path, formatValue(f.Before)))
+				default:
+					lines = append(lines, fmt.Sprintf("    ~ %s: %s -> %s", f.Path, formatValue(f.Before), formatValue(f.After)))
+				}
+			}
+		}
+		for _, line := range lines {
+			if _, err := fmt.Fprintln(w, line); err != nil {
+				return err
+			}
+		}
+		return nil
+
+	case "unified", "diff":
+		for _, d := range diffs {
+			name := path.Join(d.APIVersion, d.Kind, d.Namespace, d.Name)
+			text, err := difflib.GetUnifiedDiffString(difflib.UnifiedDiff{
+				A:        documentLines(d.before),
+				B:        documentLines(d.after),
+				FromFile: "a/" + name,
+				ToFile:   "b/" + name,
+				Context:  3,
+			})
+			if err != nil {
+				return err
+			}
+			if _, err := io.WriteString(w, text); err != nil {
+				return err
+			}
+		}
+		return nil
+
+	case "json":
+		if diffs == nil {
+			diffs = []ResourceDiff{}
+		}
+		enc := json.NewEncoder(w)
+		enc.SetIndent("", "  ")
+		return enc.Encode(diffs)
+
+	default:
+		return fmt.Errorf("unknown format: %s", format)
+	}
+}
+
+// indexResources returns the normalized resource values indexed by resource identifier.
+func indexResources(nodes []*yaml.RNode) (map[yaml.ResourceIdentifier]interface{}, error) {
+	result := make(map[yaml.ResourceIdentifier]interface{}, len(nodes))
+	for _, node := range nodes {
+		meta, err := node.GetMeta()
+		if err != nil {
+			return nil, err
+		}
+
+		var value interface{}
+		if err := node.YNode().Decode(&value); err != nil {
+			return nil, err
+		}
+
+		id := meta.GetIdentifier()
+		if _, ok := result[id]; ok {
+			return nil, fmt.Errorf("duplicate resource %s", resourceKey(id))
+		}
+		result[id] = normalize(clearReaderAnnotations(value))
+	}
+	return result, nil
+}
+
+// clearReaderAnnotations removes the annotations added while reading the resource.
+func clearReaderAnnotations(value interface{}) interface{} {
+	metadata, _ := mapValue(value)["metadata"].(map[string]interface{})
+	annotations, _ := metadata["annotations"].(map[string]interface{})
+	if annotations == nil {
+		return value
+	}
+
+	for k := range annotations {
+		if strings.HasPrefix(k, "internal.config.kubernetes.io/") {
+			delete(annotations, k)
+		}
+	}
+	for _, k := range readerAnnotations {
+		delete(annotations, k)
+	}
+	if len(annotations) == 0 {
+		delete(metadata, "annotations")
+	}
+	return value
+}
+
+// normalize sorts keyed lists so their order does not matter.
+func normalize(value interface{}) interface{} {
+	switch v := value.(type) {
+	case map[string]interface{}:
+		for k := range v {
+			v[k] = normalize(v[k])
+		}
+	case []interface{}:
+		for i := range v {
+			v[i] = normalize(v[i])
+		}
+		if key := listKey(v); key != "" {
+			sort.SliceStable(v, func(i, j int) bool {
+				return fmt.Sprint(mapValue(v[i])[key]) < fmt.Sprint(mapValue(v[j])[key])
+			})
+		}
+	}
+	return value
+}
+
+// diffValues appends the differences between two values.
+func diffValues(path string, before, after interface{}, result []FieldDiff) []FieldDiff {
+	switch b := before.(type) {
+	case map[string]interface{}:
+		a, ok := after.(map[string]interface{})
+		if !ok {
+			break
+		}
+
+		keys := make([]string, 0, len(b)+len(a))
+		for k := range b {
+			keys = append(keys, k)
+		}
+		for k := range a {
+			if _, ok := b[k]; !ok {
+				keys = append(keys, k)
+			}
+		}
+		sort.Strings(keys)
+
+		for _, k := range keys {
+			p := k
+			if path != "" {
+				p = path + "." + k
+			}
+			bv, bok := b[k]
+			av, aok := a[k]
+			switch {
+			case !aok:
+				result = append(result, FieldDiff{Path: p, Before: bv})
+			case !bok:
+				result = append(result, FieldDiff{Path: p, After: av})
+			default:
+				result = diffValues(p, bv, av, result)
+			}
+		}
+		return result
+
+	case []interface{}:
+		a, ok := after.([]interface{})
+		if !ok {
+			break
+		}
+
+		// Match elements by key when both lists use the same key
+		kb, ka := listKey(b), listKey(a)
+		key := kb
+		if key == "" {
+			key = ka
+		}
+		if key != "" && (kb == key || len(b) == 0) && (ka == key || len(a) == 0) {
+			bm, keys := keyedElements(b, key, nil)
+			am, keys := keyedElements(a, key, keys)
+			for _, k := range keys {
+				p := fmt.Sprintf("%s[%s=%s]", path, key, k)
+				bv, bok := bm[k]
+				av, aok := am[k]
+				switch {
+				case !aok:
+					result = append(result, FieldDiff{Path: p, Before: bv})
+				case !bok:
+					result = append(result, FieldDiff{Path: p, After: av})
+				default:
+					result = diffValues(p, bv, av, result)
+				}
+			}
+			return result
+		}
+
+		for i := 0; i < len(b) || i < len(a); i++ {
+			p := fmt.Sprintf("%s[%d]", path, i)
+			switch {
+			case i >= len(a):
+				result = append(result, FieldDiff{Path: p, Before: b[i]})
+			case i >= len(b):
+				result = append(result, FieldDiff{Path: p, After: a[i]})
+			default:
+				result = diffValues(p, b[i], a[i], result)
+			}
+		}
+		return result
+	}
+
+	if !reflect.DeepEqual(before, after) {
+		result = append(result, FieldDiff{Path: path, Before: before, After: after})
+	}
+	return result
+}
+
+// listKey returns the field used to uniquely identify the elements of a list.
+func listKey(list []interface{}) string {
+	if len(list) == 0 {
+		return ""
+	}
+
+KEYS:
+	for _, key := range listKeys {
+		seen := make(map[string]struct{}, len(list))
+		for _, e := range list {
+			v, ok := mapValue(e)[key]
+			if !ok {
+				continue KEYS
+			}
+			switch v.(type) {
+			case map[string]interface{}, []interface{}, nil:
+				continue KEYS
+			}
+			s := fmt.Sprint(v)
+			if _, dup := seen[s]; dup {
+				continue KEYS
+			}
+			seen[s] = struct{}{}
+		}
+		return key
+	}
+	return ""
+}
+
+// keyedElements indexes the list elements by key, appending new keys in order.
+func keyedElements(list []interface{}, key string, keys []string) (map[string]interface{}, []string) {
+	result := make(map[string]interface{}, len(list))
+	for _, e := range list {
+		k := fmt.Sprint(mapValue(e)[key])
+		result[k] = e
+		if !containsString(keys, k) {
+			keys = append(keys, k)
+		}
+	}
+	return result, keys
+}
+
+func containsString(values []string, value string) bool {
+	for _, v := range values {
+		if v == value {
+			return true
+		}
+	}
+	return false
+}
+
+func mapValue(value interface{}) map[string]interface{} {
+	m, _ := value.(map[string]interface{})
+	return m
+}
+
+// diffSymbol returns the symbol used to represent a type of change.
+func diffSymbol(t DiffType) string {
+	switch t {
+	case DiffAdded:
+		return "+"
+	case DiffRemoved:
+		return "-"
+	default:
+		return "~"
+	}
+}
+
+// resourceKey returns a display name for a resource identifier.
+func resourceKey(id yaml.ResourceIdentifier) string {
+	name := id.Name
+	if id.Namespace != "" {
+		name = id.Namespace + "/" + name
+	}
+	return id.APIVersion + " " + id.Kind + " " + name
+}
+
+// formatValue returns a compact representation of a field value.
+func formatValue(value interface{}) string {
+	data, err := json.Marshal(value)
+	if err != nil {
+		return fmt.Sprint(value)
+	}
+	return string(data)
+}
+
+// documentLines returns the lines of the YAML representation of a normalized resource.
+func documentLines(value interface{}) []string {
+	if value == nil {
+		return nil
+	}
+	data, err := yaml.Marshal(value)
+	if err != nil {
+		return []string{fmt.Sprintln(value)}
+	}
+	return difflib.SplitLines(strings.TrimSuffix(string(data), "\n"))
+}
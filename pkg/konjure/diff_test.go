@@ -0,0 +1,206 @@
+/*
+Copyright 2023 GramLabs, Inc.
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package konjure
+
+import (
+	"bytes"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+	"sigs.k8s.io/kustomize/kyaml/kio"
+	"sigs.k8s.io/kustomize/kyaml/yaml"
+)
+
+func TestDiff(t *testing.T) {
+	cases := []struct {
+		desc     string
+		before   string
+		after    string
+		expected []ResourceDiff
+	}{
+		{
+			desc: "no changes",
+			before: `apiVersion: v1
+kind: ConfigMap
+metadata:
+  name: test
+data:
+  a: b
+  c: d
+`,
+			after: `{"kind": "ConfigMap", "apiVersion": "v1", "data": {"c": "d", "a": "b"}, "metadata": {"name": "test"}}`,
+		},
+		{
+			desc: "reader annotations",
+			before: `apiVersion: v1
+kind: ConfigMap
+metadata:
+  name: test
+  annotations:
+    config.kubernetes.io/path: before.yaml
+    konjure.stormforge.io/provenance: before
+`,
+			after: `apiVersion: v1
+kind: ConfigMap
+metadata:
+  name: test
+  annotations:
+    internal.config.kubernetes.io/path: after.yaml
+`,
+		},
+		{
+			desc: "added and removed",
+			before: `apiVersion: v1
+kind: Service
+metadata:
+  name: test
+`,
+			after: `apiVersion: v1
+kind: ConfigMap
+metadata:
+  name: test
+  namespace: default
+`,
+			expected: []ResourceDiff{
+				{
+					ResourceIdentifier: yaml.ResourceIdentifier{
+						TypeMeta: yaml.TypeMeta{APIVersion: "v1", Kind: "ConfigMap"},
+						NameMeta: yaml.NameMeta{Name: "test", Namespace: "default"},
+					},
+					Type: DiffAdded,
+				},
+				{
+					ResourceIdentifier: yaml.ResourceIdentifier{
+						TypeMeta: yaml.TypeMeta{APIVersion: "v1", Kind: "Service"},
+						NameMeta: yaml.NameMeta{Name: "test"},
+					},
+					Type: DiffRemoved,
+				},
+			},
+		},
+		{
+			desc: "keyed list",
+			before: `apiVersion: apps/v1
+kind: Deployment
+metadata:
+  name: test
+spec:
+  replicas: 1
+  template:
+    spec:
+      containers:
+      - name: a
+        image: a:1
+      - name: b
+        image: b:1
+        args: [x, y]
+`,
+			after: `apiVersion: apps/v1
+kind: Deployment
+metadata:
+  name: test
+spec:
+  template:
+    spec:
+      containers:
+      - name: b
+        image: b:1
+        args: [x, z]
+      - name: a
+        image: a:2
+`,
+			expected: []ResourceDiff{
+				{
+					ResourceIdentifier: yaml.ResourceIdentifier{
+						TypeMeta: yaml.TypeMeta{APIVersion: "apps/v1", Kind: "Deployment"},
+						NameMeta: yaml.NameMeta{Name: "test"},
+					},
+					Type: DiffChanged,
+					Fields: []FieldDiff{
+						{Path: "spec.replicas", Before: 1},
+						{Path: "spec.template.spec.containers[name=a].image", Before: "a:1", After: "a:2"},
+						{Path: "spec.template.spec.containers[name=b].args[1]", Before: "y", After: "z"},
+					},
+				},
+			},
+		},
+	}
+	for _, c := range cases {
+		t.Run(c.desc, func(t *testing.T) {
+			before, err := kio.FromBytes([]byte(c.before))
+			require.NoError(t, err)
+			after, err := kio.FromBytes([]byte(c.after))
+			require.NoError(t, err)
+
+			actual, err := Diff(before, after)
+			require.NoError(t, err)
+			require.Len(t, actual, len(c.expected))
+			for i := range actual {
+				assert.Equal(t, c.expected[i].ResourceIdentifier, actual[i].ResourceIdentifier)
+				assert.Equal(t, c.expected[i].Type, actual[i].Type)
+				assert.Equal(t, c.expected[i].Fields, actual[i].Fields)
+			}
+		})
+	}
+}
+
+func TestWriteDiff(t *testing.T) {
+	before, err := kio.FromBytes([]byte(`apiVersion: v1
+kind: ConfigMap
+metadata:
+  name: test
+data:
+  a: b
+`))
+	require.NoError(t, err)
+	after, err := kio.FromBytes([]byte(`apiVersion: v1
+kind: ConfigMap
+metadata:
+  name: test
+data:
+  a: c
+`))
+	require.NoError(t, err)
+
+	diffs, err := Diff(before, after)
+	require.NoError(t, err)
+
+	var text bytes.Buffer
+	require.NoError(t, WriteDiff(&text, "text", diffs))
+	assert.Equal(t, `~ v1 ConfigMap test
+    ~ data.a: "b" -> "c"
+`, text.String())
+
+	var unified bytes.Buffer
+	require.NoError(t, WriteDiff(&unified, "unified", diffs))
+	assert.Equal(t, `--- a/v1/ConfigMap/test
++++ b/v1/ConfigMap/test
+@@ -1,6 +1,6 @@
+ apiVersion: v1
+ data:
+-  a: b
++  a: c
+ kind: ConfigMap
+ metadata:
+   name: test
+`, unified.String())
+
+	var empty bytes.Buffer
+	require.NoError(t, WriteDiff(&empty, "json", nil))
+	assert.Equal(t, "[]\n", empty.String())
+}
@@ -17,10 +17,14 @@ limitations under the License.
 package konjure
 
 import (
+	"context"
+	"errors"
 	"io"
 	"os/exec"
+	"time"
 
 	"github.com/thestormforge/konjure/internal/readers"
+	konjurev1beta2 "github.com/thestormforge/konjure/pkg/api/core/v1beta2"
 	"github.com/thestormforge/konjure/pkg/filters"
 	"sigs.k8s.io/kustomize/kyaml/kio"
 	kiofilters "sigs.k8s.io/kustomize/kyaml/kio/filters"
@@ -31,12 +35,22 @@ import (
 type Filter struct {
 	// The number of times to recursively filter the resource list.
 	Depth int
+	// The maximum number of Konjure resources to expand concurrently.
+	Concurrency int
 	// The default reader to use, defaults to stdin.
 	DefaultReader io.Reader
+	// Patches applied to the output.
+	Patches []konjurev1beta2.PatchSpec
+	// Filter used to apply common transformations to the output.
+	TransformFilter filters.TransformFilter
 	// Filter used to reduce the output to application definitions.
 	ApplicationFilter filters.ApplicationFilter
 	// Filter used to reduce the output to workloads.
 	WorkloadFilter filters.WorkloadFilter
+	// Filter used to check the output against the schemas of each resource type.
+	ValidationFilter filters.ValidationFilter
+	// Filter used to check the output against policy rules.
+	PolicyFilter PolicyFilter
 	// Filter to determine which resources are retained.
 	filters.ResourceMetaFilter
 	// Flag indicating that status fields should not be stripped.
@@ -53,14 +67,58 @@ type Filter struct {
 	Kubeconfig string
 	// Override the default types used when fetching Kubernetes resources.
 	KubernetesTypes []string
+	// Flag indicating that server populated fields should be removed from Kubernetes resources.
+	KubernetesExport bool
+	// Flag indicating that exported Kubernetes resources should not include API default values.
+	KubernetesRemoveDefaults bool
 	// Override the default Kubectl executor.
+	//
+	// Deprecated: Kubernetes resources are read using the Kubernetes API instead
+	// of kubectl, filtering fails if this is set.
 	KubectlExecutor func(cmd *exec.Cmd) ([]byte, error)
+	// Override the default Helm executor.
+	HelmExecutor func(cmd *exec.Cmd) ([]byte, error)
+	// The release name used to render Helm charts which do not specify one.
+	HelmReleaseName string
+	// The release namespace used to render Helm charts which do not specify one.
+	HelmReleaseNamespace string
+	// Additional values used to render every Helm chart.
+	HelmValues []konjurev1beta2.HelmValue
+	// Additional external variables used to evaluate every Jsonnet program.
+	JsonnetExternalVariables []konjurev1beta2.JsonnetParameter
 	// Override the default Kustomize executor.
 	KustomizeExecutor func(cmd *exec.Cmd) ([]byte, error)
+	// The token used to authenticate to HTTP(S) Git repositories.
+	GitToken string
+	// The directory used to cache the expansion of remote resources, leave
+	// empty to disable caching.
+	CacheDirectory string
+	// The path to the lock file used to pin remote resources.
+	LockFile string
+	// Flag indicating the lock file should be rewritten instead of verified.
+	UpdateLock bool
+	// Optional explanation used to record how Konjure resources were expanded.
+	Explanation *Explanation
+	// Optional tracker used to record the local files read during expansion.
+	Tracker *Tracker
+	// The context used to cancel the expansion of Konjure resources.
+	Context context.Context
+	// The maximum amount of time to spend expanding Konjure resources, zero for no limit.
+	Timeout time.Duration
 }
 
+// Explanation records the tree of Konjure resource expansions.
+type Explanation = readers.Explanation
+
+// Tracker records the local files read during expansion.
+type Tracker = readers.Tracker
+
 // Filter evaluates Konjure resources according to the filter configuration.
 func (f *Filter) Filter(nodes []*yaml.RNode) ([]*yaml.RNode, error) {
+	if f.KubectlExecutor != nil {
+		return nil, errors.New("kubectl executor is no longer supported, Kubernetes resources are read using the Kubernetes API")
+	}
+
 	defaultTypes := f.KubernetesTypes
 	if len(defaultTypes) == 0 {
 		// This represents the original set of default types from early StormForge products
@@ -72,22 +130,69 @@ func (f *Filter) Filter(nodes []*yaml.RNode) ([]*yaml.RNode, error) {
 		defaultTypes = appendDistinct(defaultTypes, "daemonsets", "deployments", "statefulsets", "replicasets", "cronjobs", "pods")
 	}
 
+	var cache *readers.Cache
+	if f.CacheDirectory != "" {
+		cache = &readers.Cache{Dir: f.CacheDirectory}
+	}
+
+	var lock *readers.Lock
+	if f.UpdateLock {
+		lock = &readers.Lock{Update: true}
+	} else if f.LockFile != "" {
+		var err error
+		if lock, err = readers.ReadLockFile(f.LockFile); err != nil {
+			return nil, err
+		}
+	}
+
+	ctx := f.Context
+	if ctx == nil {
+		ctx = context.Background()
+	}
+	if f.Timeout > 0 {
+		var cancel context.CancelFunc
+		ctx, cancel = context.WithTimeout(ctx, f.Timeout)
+		defer cancel()
+	}
+
+	// Policies found during expansion only apply to this invocation
+	policies := f.PolicyFilter
+
+	// Definitions found during expansion are collected before any resources are removed
+	validation := f.ValidationFilter
+
 	p := &filters.Pipeline{
 		Inputs: []kio.Reader{kio.ResourceNodeSlice(nodes)},
 		Filters: []kio.Filter{
 			&readers.Filter{
-				Depth: f.Depth,
+				Depth:       f.Depth,
+				Concurrency: f.Concurrency,
+				Explanation: f.Explanation,
+				Context:     ctx,
 				ReaderOptions: []readers.Option{
 					readers.WithDefaultInputStream(f.DefaultReader),
 					readers.WithWorkingDirectory(f.WorkingDirectory),
 					readers.WithRecursiveDirectories(f.RecursiveDirectories),
 					readers.WithKubeconfig(f.Kubeconfig),
-					readers.WithKubectlExecutor(f.KubectlExecutor),
+					readers.WithHelmExecutor(f.HelmExecutor),
+					readers.WithHelmRelease(f.HelmReleaseName, f.HelmReleaseNamespace),
+					readers.WithHelmValues(f.HelmValues...),
+					readers.WithJsonnetExternalVariables(f.JsonnetExternalVariables...),
 					readers.WithKustomizeExecutor(f.KustomizeExecutor),
 					readers.WithDefaultTypes(defaultTypes...),
+					readers.WithExport(f.KubernetesExport, f.KubernetesRemoveDefaults),
+					readers.WithGitToken(f.GitToken),
+					readers.WithCache(cache),
+					readers.WithLock(lock),
+					readers.WithTracker(f.Tracker),
 				},
 			},
 
+			kio.FilterFunc(policies.collect),
+			&readers.PatchFilter{Patches: f.Patches},
+			&f.TransformFilter,
+			&filters.ContentHashFilter{},
+			validation.Collect(),
 			&f.ApplicationFilter,
 			&f.WorkloadFilter,
 			&f.ResourceMetaFilter,
@@ -106,7 +211,20 @@ func (f *Filter) Filter(nodes []*yaml.RNode) ([]*yaml.RNode, error) {
 		p.Filters = append(p.Filters, &kiofilters.FormatFilter{})
 	}
 
-	return p.Read()
+	p.Filters = append(p.Filters, &validation, &policies)
+
+	result, err := p.Read()
+	if err != nil {
+		return nil, err
+	}
+
+	if f.UpdateLock && f.LockFile != "" {
+		if err := lock.WriteFile(f.LockFile); err != nil {
+			return nil, err
+		}
+	}
+
+	return result, nil
 }
 
 func appendDistinct(values []string, more ...string) []string {
@@ -0,0 +1,33 @@
+/*
+Copyright 2023 GramLabs, Inc.
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package konjure
+
+import (
+	"os/exec"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+)
+
+func TestFilter_Filter_kubectlExecutor(t *testing.T) {
+	f := &Filter{
+		KubectlExecutor: func(cmd *exec.Cmd) ([]byte, error) { return nil, nil },
+	}
+
+	_, err := f.Filter(nil)
+	assert.EqualError(t, err, "kubectl executor is no longer supported, Kubernetes resources are read using the Kubernetes API")
+}
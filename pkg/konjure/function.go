@@ -0,0 +1,126 @@
+/*
+Copyright 2023 GramLabs, Inc.
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package konjure
+
+import (
+	"fmt"
+
+	"github.com/thestormforge/konjure/internal/readers"
+	konjurev1beta2 "github.com/thestormforge/konjure/pkg/api/core/v1beta2"
+	"sigs.k8s.io/kustomize/kyaml/fn/framework"
+	"sigs.k8s.io/kustomize/kyaml/kio/kioutil"
+	"sigs.k8s.io/kustomize/kyaml/yaml"
+)
+
+// Function is a KRM function which expands Konjure resources. The function
+// configuration may be a Konjure resource (or a `List` of Konjure resources)
+// which is expanded along with any Konjure resources in the resource list items.
+// Status fields are always kept since the items passed through the function
+// belong to the caller.
+type Function struct {
+	Filter
+	// Flag to keep the provenance annotations on the expanded resources.
+	KeepReaderAnnotations bool
+}
+
+// Process expands the Konjure resources in the supplied resource list. Errors
+// are reported in the results of the resource list.
+func (fn *Function) Process(rl *framework.ResourceList) error {
+	configs, err := functionConfigResources(rl.FunctionConfig)
+	if err != nil {
+		return fail(rl, err)
+	}
+
+	f := fn.Filter
+	f.KeepStatus = true
+	items, err := f.Filter(append(rl.Items, configs...))
+	if err != nil {
+		return fail(rl, err)
+	}
+
+	// Expanded resources should not look like they came from the local file system
+	for _, item := range items {
+		if _, ok := item.GetAnnotations()[readers.ProvenanceAnnotation]; !ok {
+			continue
+		}
+
+		annotations := []string{
+			kioutil.PathAnnotation,
+			kioutil.IndexAnnotation,
+			kioutil.LegacyPathAnnotation,
+			kioutil.LegacyIndexAnnotation,
+		}
+		if !fn.KeepReaderAnnotations {
+			annotations = append(annotations, readers.ProvenanceAnnotation)
+		}
+		for _, a := range annotations {
+			if _, err := item.Pipe(yaml.ClearAnnotation(a)); err != nil {
+				return fail(rl, err)
+			}
+		}
+	}
+
+	rl.Items = items
+	return nil
+}
+
+// fail records the error in the results of the resource list.
+func fail(rl *framework.ResourceList, err error) error {
+	rl.Results = append(rl.Results, &framework.Result{
+		Message:  err.Error(),
+		Severity: framework.Error,
+	})
+	return rl.Results
+}
+
+// functionConfigResources returns the Konjure resources from the function configuration.
+func functionConfigResources(fc *yaml.RNode) ([]*yaml.RNode, error) {
+	if fc == nil || fc.IsNilOrEmpty() {
+		return nil, nil
+	}
+
+	m, err := fc.GetMeta()
+	if err != nil {
+		return nil, err
+	}
+
+	switch {
+	case m.APIVersion == konjurev1beta2.APIVersion:
+		// Use a copy so expansion does not modify the function config
+		return []*yaml.RNode{fc.Copy()}, nil
+
+	case m.Kind == "List":
+		items, err := fc.Pipe(yaml.Lookup("items"))
+		if err != nil || items == nil {
+			return nil, err
+		}
+		result, err := items.Elements()
+		if err != nil {
+			return nil, err
+		}
+		for i, item := range result {
+			if item.GetApiVersion() != konjurev1beta2.APIVersion {
+				return nil, fmt.Errorf("function config list item is not a Konjure resource: %s/%s", item.GetApiVersion(), item.GetKind())
+			}
+			result[i] = item.Copy()
+		}
+		return result, nil
+
+	default:
+		return nil, fmt.Errorf("function config is not a Konjure resource: %s/%s", m.APIVersion, m.Kind)
+	}
+}
@@ -0,0 +1,183 @@
+/*
+Copyright 2023 GramLabs, Inc.
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package konjure
+
+import (
+	"bytes"
+	"strings"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"sigs.k8s.io/kustomize/kyaml/fn/framework"
+	"sigs.k8s.io/kustomize/kyaml/kio"
+)
+
+func TestFunction_Process(t *testing.T) {
+	cases := []struct {
+		desc     string
+		input    string
+		expected string
+		err      string
+	}{
+		{
+			desc: "function config",
+			input: `apiVersion: config.kubernetes.io/v1
+kind: ResourceList
+items:
+- apiVersion: v1
+  kind: ConfigMap
+  metadata:
+    name: test
+functionConfig:
+  apiVersion: konjure.stormforge.io/v1beta2
+  kind: Secret
+  metadata:
+    name: generator
+  secretName: test
+  literals:
+  - foo=bar
+`,
+			expected: `apiVersion: config.kubernetes.io/v1
+kind: ResourceList
+items:
+- apiVersion: v1
+  kind: ConfigMap
+  metadata:
+    name: test
+- apiVersion: v1
+  kind: Secret
+  metadata:
+    name: test
+  data:
+    foo: YmFy
+functionConfig:
+  apiVersion: konjure.stormforge.io/v1beta2
+  kind: Secret
+  metadata:
+    name: generator
+  secretName: test
+  literals:
+  - foo=bar
+`,
+		},
+		{
+			desc: "function config list",
+			input: `apiVersion: config.kubernetes.io/v1
+kind: ResourceList
+items: []
+functionConfig:
+  apiVersion: v1
+  kind: List
+  items:
+  - apiVersion: konjure.stormforge.io/v1beta2
+    kind: Secret
+    secretName: foo
+  - apiVersion: konjure.stormforge.io/v1beta2
+    kind: Secret
+    secretName: bar
+`,
+			expected: `apiVersion: config.kubernetes.io/v1
+kind: ResourceList
+items:
+- apiVersion: v1
+  kind: Secret
+  metadata:
+    name: foo
+- apiVersion: v1
+  kind: Secret
+  metadata:
+    name: bar
+functionConfig:
+  apiVersion: v1
+  kind: List
+  items:
+  - apiVersion: konjure.stormforge.io/v1beta2
+    kind: Secret
+    secretName: foo
+  - apiVersion: konjure.stormforge.io/v1beta2
+    kind: Secret
+    secretName: bar
+`,
+		},
+		{
+			desc: "keep status",
+			input: `apiVersion: config.kubernetes.io/v1
+kind: ResourceList
+items:
+- apiVersion: apps/v1
+  kind: Deployment
+  metadata:
+    name: test
+  status:
+    replicas: 1
+`,
+			expected: `apiVersion: config.kubernetes.io/v1
+kind: ResourceList
+items:
+- apiVersion: apps/v1
+  kind: Deployment
+  metadata:
+    name: test
+  status:
+    replicas: 1
+`,
+		},
+		{
+			desc: "invalid function config",
+			input: `apiVersion: config.kubernetes.io/v1
+kind: ResourceList
+items: []
+functionConfig:
+  apiVersion: v1
+  kind: ConfigMap
+  metadata:
+    name: test
+`,
+			expected: `apiVersion: config.kubernetes.io/v1
+kind: ResourceList
+items: []
+functionConfig:
+  apiVersion: v1
+  kind: ConfigMap
+  metadata:
+    name: test
+results:
+- message: 'function config is not a Konjure resource: v1/ConfigMap'
+  severity: error
+`,
+			err: "function config is not a Konjure resource",
+		},
+	}
+	for _, c := range cases {
+		t.Run(c.desc, func(t *testing.T) {
+			var out bytes.Buffer
+			err := framework.Execute(&Function{Filter: Filter{Depth: 10}}, &kio.ByteReadWriter{
+				Reader:                strings.NewReader(c.input),
+				Writer:                &out,
+				KeepReaderAnnotations: true,
+			})
+			if c.err != "" {
+				if assert.Error(t, err) {
+					assert.Contains(t, err.Error(), c.err)
+				}
+			} else {
+				assert.NoError(t, err)
+			}
+			assert.Equal(t, c.expected, out.String())
+		})
+	}
+}
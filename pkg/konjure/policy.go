@@ -0,0 +1,200 @@
+/*
+Copyright 2023 GramLabs, Inc.
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package konjure
+
+import (
+	"fmt"
+	"io"
+	"strings"
+
+	"github.com/google/cel-go/cel"
+	"github.com/google/cel-go/ext"
+	konjurev1beta2 "github.com/thestormforge/konjure/pkg/api/core/v1beta2"
+	"github.com/thestormforge/konjure/pkg/filters"
+	"sigs.k8s.io/kustomize/kyaml/yaml"
+)
+
+const (
+	// PolicyDeny is the severity of rules which fail the policy check.
+	PolicyDeny = "deny"
+	// PolicyWarn is the severity of rules which are only reported.
+	PolicyWarn = "warn"
+)
+
+// PolicyFilter evaluates policy rules against the expanded resources. In
+// addition to the explicitly configured policies, any Konjure `Policy`
+// resources in the expanded resources are removed and evaluated.
+type PolicyFilter struct {
+	// Additional policies to evaluate.
+	Policies []konjurev1beta2.Policy
+	// The stream used to report violations and a summary of the policy check.
+	Report io.Writer
+
+	collected []konjurev1beta2.Policy
+}
+
+// PolicyViolation describes a resource which does not satisfy a policy rule.
+type PolicyViolation struct {
+	yaml.ResourceIdentifier
+	// The name of the rule which was violated.
+	Rule string
+	// The severity of the rule.
+	Severity string
+	// The description of the violation.
+	Message string
+}
+
+func (v *PolicyViolation) String() string {
+	name := v.Name
+	if v.Namespace != "" {
+		name = v.Namespace + "/" + name
+	}
+	return fmt.Sprintf("%s: %s %s %s: %s: %s", v.Severity, v.APIVersion, v.Kind, name, v.Rule, v.Message)
+}
+
+// PolicyError is returned when resources violate rules with the "deny" severity.
+type PolicyError struct {
+	Violations []PolicyViolation
+}
+
+func (e *PolicyError) Error() string {
+	return fmt.Sprintf("policy check failed with %d violation(s)", len(e.Violations))
+}
+
+// collect removes the Konjure policies from the resource list so they can be evaluated later.
+func (f *PolicyFilter) collect(nodes []*yaml.RNode) ([]*yaml.RNode, error) {
+	result := make([]*yaml.RNode, 0, len(nodes))
+	for _, node := range nodes {
+		if node.GetApiVersion() != konjurev1beta2.APIVersion || node.GetKind() != "Policy" {
+			result = append(result, node)
+			continue
+		}
+
+		p := konjurev1beta2.Policy{}
+		if err := node.YNode().Decode(&p); err != nil {
+			return nil, err
+		}
+		f.collected = append(f.collected, p)
+	}
+	return result, nil
+}
+
+// Filter evaluates the policy rules, failing if any "deny" rule is violated.
+func (f *PolicyFilter) Filter(nodes []*yaml.RNode) ([]*yaml.RNode, error) {
+	var rules []konjurev1beta2.PolicyRule
+	for _, p := range append(f.Policies[:len(f.Policies):len(f.Policies)], f.collected...) {
+		rules = append(rules, p.Rules...)
+	}
+	if len(rules) == 0 {
+		return nodes, nil
+	}
+
+	env, err := cel.NewEnv(cel.Variable("object", cel.DynType), ext.Strings())
+	if err != nil {
+		return nil, err
+	}
+
+	var violations, denied []PolicyViolation
+	for i := range rules {
+		vs, err := evaluateRule(env, &rules[i], nodes)
+		if err != nil {
+			return nil, err
+		}
+		for _, v := range vs {
+			violations = append(violations, v)
+			if v.Severity == PolicyDeny {
+				denied = append(denied, v)
+			}
+		}
+	}
+
+	if f.Report != nil {
+		for i := range violations {
+			_, _ = fmt.Fprintln(f.Report, violations[i].String())
+		}
+		_, _ = fmt.Fprintf(f.Report, "policy check: %d resource(s), %d rule(s), %d denied, %d warning(s)\n",
+			len(nodes), len(rules), len(denied), len(violations)-len(denied))
+	}
+
+	if len(denied) > 0 {
+		return nil, &PolicyError{Violations: denied}
+	}
+	return nodes, nil
+}
+
+// evaluateRule returns the violations of a single rule.
+func evaluateRule(env *cel.Env, rule *konjurev1beta2.PolicyRule, nodes []*yaml.RNode) ([]PolicyViolation, error) {
+	severity := strings.ToLower(rule.Severity)
+	switch severity {
+	case "":
+		severity = PolicyDeny
+	case PolicyDeny, PolicyWarn:
+	default:
+		return nil, fmt.Errorf("policy rule %q has an invalid severity: %s", rule.Name, rule.Severity)
+	}
+
+	ast, iss := env.Compile(rule.Expression)
+	if iss.Err() != nil {
+		return nil, fmt.Errorf("policy rule %q: %w", rule.Name, iss.Err())
+	}
+	prg, err := env.Program(ast)
+	if err != nil {
+		return nil, fmt.Errorf("policy rule %q: %w", rule.Name, err)
+	}
+
+	match := filters.ResourceMetaFilter(rule.Match)
+	matched, err := match.Filter(nodes)
+	if err != nil {
+		return nil, err
+	}
+
+	var result []PolicyViolation
+	for _, node := range matched {
+		md, err := node.GetMeta()
+		if err != nil {
+			return nil, err
+		}
+
+		obj, err := node.Map()
+		if err != nil {
+			return nil, err
+		}
+
+		message := rule.Message
+		if message == "" {
+			message = "failed expression: " + rule.Expression
+		}
+
+		// Evaluation errors (e.g. missing fields) are treated as violations
+		out, _, err := prg.Eval(map[string]interface{}{"object": obj})
+		if err != nil {
+			message = fmt.Sprintf("%s (%v)", message, err)
+		} else if passed, ok := out.Value().(bool); !ok {
+			message = fmt.Sprintf("%s (expression returned %s, not bool)", message, out.Type().TypeName())
+		} else if passed {
+			continue
+		}
+
+		result = append(result, PolicyViolation{
+			ResourceIdentifier: md.GetIdentifier(),
+			Rule:               rule.Name,
+			Severity:           severity,
+			Message:            message,
+		})
+	}
+	return result, nil
+}
@@ -0,0 +1,131 @@
+/*
+Copyright 2023 GramLabs, Inc.
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package konjure
+
+import (
+	"bytes"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+	konjurev1beta2 "github.com/thestormforge/konjure/pkg/api/core/v1beta2"
+	"sigs.k8s.io/kustomize/kyaml/kio"
+)
+
+func TestPolicyFilter(t *testing.T) {
+	resources := `apiVersion: apps/v1
+kind: Deployment
+metadata:
+  name: good
+  labels:
+    app.kubernetes.io/name: good
+spec:
+  template:
+    spec:
+      containers:
+      - name: app
+        image: app:1.0
+---
+apiVersion: apps/v1
+kind: Deployment
+metadata:
+  name: bad
+spec:
+  template:
+    spec:
+      containers:
+      - name: app
+        image: app:latest
+---
+apiVersion: v1
+kind: ConfigMap
+metadata:
+  name: ignored
+`
+
+	policy := `apiVersion: konjure.stormforge.io/v1beta2
+kind: Policy
+rules:
+- name: no-latest
+  match:
+    kind: Deployment
+  expression: "!object.spec.template.spec.containers.exists(c, c.image.endsWith(':latest'))"
+  message: images must not use the latest tag
+  severity: warn
+`
+
+	labelRule := konjurev1beta2.PolicyRule{
+		Name:       "app-name",
+		Match:      konjurev1beta2.Selector{Kind: "Deployment"},
+		Expression: "'app.kubernetes.io/name' in object.metadata.labels",
+	}
+
+	cases := []struct {
+		desc     string
+		policies []konjurev1beta2.Policy
+		input    string
+		report   string
+		denied   int
+	}{
+		{
+			desc:  "no policies",
+			input: resources,
+		},
+		{
+			desc:  "policy in resources",
+			input: resources + "---\n" + policy,
+			report: `warn: apps/v1 Deployment bad: no-latest: images must not use the latest tag
+policy check: 3 resource(s), 1 rule(s), 0 denied, 1 warning(s)
+`,
+		},
+		{
+			desc:     "configured policy",
+			policies: []konjurev1beta2.Policy{{Rules: []konjurev1beta2.PolicyRule{labelRule}}},
+			input:    resources,
+			report: `deny: apps/v1 Deployment bad: app-name: failed expression: 'app.kubernetes.io/name' in object.metadata.labels (no such key: labels)
+policy check: 3 resource(s), 1 rule(s), 1 denied, 0 warning(s)
+`,
+			denied: 1,
+		},
+	}
+	for _, c := range cases {
+		t.Run(c.desc, func(t *testing.T) {
+			nodes, err := kio.FromBytes([]byte(c.input))
+			require.NoError(t, err)
+
+			report := &bytes.Buffer{}
+			f := &Filter{
+				Depth:        100,
+				KeepComments: true,
+				PolicyFilter: PolicyFilter{Policies: c.policies, Report: report},
+			}
+
+			actual, err := f.Filter(nodes)
+			assert.Equal(t, c.report, report.String())
+			if c.denied > 0 {
+				var perr *PolicyError
+				if assert.ErrorAs(t, err, &perr) {
+					assert.Len(t, perr.Violations, c.denied)
+				}
+				return
+			}
+			if assert.NoError(t, err) {
+				assert.Len(t, actual, 3)
+			}
+		})
+	}
+}
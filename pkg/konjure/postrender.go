@@ -0,0 +1,68 @@
+/*
+Copyright 2023 GramLabs, Inc.
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package konjure
+
+import (
+	"bytes"
+
+	"helm.sh/helm/v3/pkg/postrender"
+	"sigs.k8s.io/kustomize/kyaml/kio"
+	"sigs.k8s.io/kustomize/kyaml/kio/filters"
+	"sigs.k8s.io/kustomize/kyaml/kio/kioutil"
+)
+
+// PostRenderer is a Helm post-renderer which applies the Konjure filter to the
+// rendered chart manifests.
+type PostRenderer struct {
+	// The filter applied to the rendered manifests.
+	Filter Filter
+	// The writer used to produce the modified manifests, the format is always YAML.
+	Writer Writer
+	// Additional Konjure resources to include with the rendered manifests.
+	Resources Resources
+}
+
+var _ postrender.PostRenderer = &PostRenderer{}
+
+// Run filters the rendered manifests, returning the modified manifests.
+func (pr *PostRenderer) Run(renderedManifests *bytes.Buffer) (*bytes.Buffer, error) {
+	result := &bytes.Buffer{}
+
+	// Helm only accepts a plain YAML stream
+	w := pr.Writer
+	w.Format = "yaml"
+	w.Writer = result
+	if !w.KeepReaderAnnotations {
+		w.ClearAnnotations = append(w.ClearAnnotations[:len(w.ClearAnnotations):len(w.ClearAnnotations)],
+			kioutil.PathAnnotation,
+			kioutil.LegacyPathAnnotation,
+			filters.FmtAnnotation,
+		)
+	}
+
+	err := kio.Pipeline{
+		Inputs:                []kio.Reader{&kio.ByteReader{Reader: renderedManifests}, pr.Resources},
+		Filters:               []kio.Filter{&pr.Filter},
+		Outputs:               []kio.Writer{&w},
+		ContinueOnEmptyResult: true,
+	}.Execute()
+	if err != nil {
+		return nil, err
+	}
+
+	return result, nil
+}
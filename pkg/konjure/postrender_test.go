@@ -0,0 +1,139 @@
+/*
+Copyright 2023 GramLabs, Inc.
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package konjure
+
+import (
+	"bytes"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	konjurev1beta2 "github.com/thestormforge/konjure/pkg/api/core/v1beta2"
+	"github.com/thestormforge/konjure/pkg/filters"
+)
+
+func TestPostRenderer_Run(t *testing.T) {
+	manifests := `---
+# Source: test/templates/configmap.yaml
+apiVersion: v1
+kind: ConfigMap
+metadata:
+  name: test
+data:
+  foo: bar
+---
+# Source: test/templates/deployment.yaml
+apiVersion: apps/v1
+kind: Deployment
+metadata:
+  name: test
+spec:
+  replicas: 1
+status:
+  replicas: 1
+`
+
+	cases := []struct {
+		desc         string
+		postRenderer PostRenderer
+		expected     string
+	}{
+		{
+			desc: "strip status",
+			postRenderer: PostRenderer{
+				Filter: Filter{KeepComments: true},
+			},
+			expected: `# Source: test/templates/configmap.yaml
+apiVersion: v1
+kind: ConfigMap
+metadata:
+  name: test
+data:
+  foo: bar
+---
+# Source: test/templates/deployment.yaml
+apiVersion: apps/v1
+kind: Deployment
+metadata:
+  name: test
+spec:
+  replicas: 1
+`,
+		},
+		{
+			desc: "resource meta filter",
+			postRenderer: PostRenderer{
+				Filter: Filter{
+					ResourceMetaFilter: filters.ResourceMetaFilter{Kind: "Deployment"},
+					KeepStatus:         true,
+				},
+			},
+			expected: `apiVersion: apps/v1
+kind: Deployment
+metadata:
+  name: test
+spec:
+  replicas: 1
+status:
+  replicas: 1
+`,
+		},
+		{
+			desc: "patches and transformations",
+			postRenderer: PostRenderer{
+				Filter: Filter{
+					ResourceMetaFilter: filters.ResourceMetaFilter{Kind: "Deployment"},
+					Patches: []konjurev1beta2.PatchSpec{
+						{Target: konjurev1beta2.Selector{Kind: "Deployment"}, Values: []string{"spec.replicas=3"}},
+					},
+					TransformFilter: filters.TransformFilter{NamePrefix: "prod-"},
+				},
+			},
+			expected: `apiVersion: apps/v1
+kind: Deployment
+metadata:
+  name: prod-test
+spec:
+  replicas: 3
+`,
+		},
+		{
+			desc: "additional resources",
+			postRenderer: PostRenderer{
+				Filter: Filter{
+					Depth:              1,
+					ResourceMetaFilter: filters.ResourceMetaFilter{Kind: "Secret"},
+				},
+				Resources: Resources{{Secret: &konjurev1beta2.Secret{SecretName: "test", LiteralSources: []string{"foo=bar"}}}},
+			},
+			expected: `apiVersion: v1
+kind: Secret
+metadata:
+  name: test
+data:
+  foo: YmFy
+`,
+		},
+	}
+	for _, c := range cases {
+		t.Run(c.desc, func(t *testing.T) {
+			actual, err := c.postRenderer.Run(bytes.NewBufferString(manifests))
+			if assert.NoError(t, err) {
+				assert.Equal(t, c.expected, actual.String())
+			}
+		})
+	}
+}
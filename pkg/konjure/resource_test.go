@@ -90,6 +90,16 @@ func TestResource_UnmarshalJSON(t *testing.T) {
 				},
 			},
 		},
+		{
+			desc:    "helm object",
+			rawJSON: `{"helm":{"repo":"https://example.com/charts","chart":"test"}}`,
+			expected: Resource{
+				Helm: &konjurev1beta2.Helm{
+					Repository: "https://example.com/charts",
+					Chart:      "test",
+				},
+			},
+		},
 		{
 			desc:    "data",
 			rawJSON: `"data:;base64,` + base64.URLEncoding.EncodeToString([]byte(testResource)) + `"`,
@@ -0,0 +1,299 @@
+/*
+Copyright 2023 GramLabs, Inc.
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package konjure
+
+import (
+	"bytes"
+	"context"
+	"fmt"
+	"io"
+	"os"
+	"path/filepath"
+	"sort"
+	"strings"
+	"time"
+
+	"sigs.k8s.io/kustomize/kyaml/yaml"
+)
+
+// Watcher expands resources again each time the local files read by the
+// previous expansion change.
+type Watcher struct {
+	// The filter used to expand the resources.
+	Filter Filter
+	// The writer used to emit the expanded resources.
+	Writer Writer
+	// The resources to expand.
+	Resources Resources
+	// Optional directory to write each expanded resource to as a separate file
+	// instead of using the writer's output stream.
+	Directory string
+	// How often to check the local files for changes, defaults to 500ms.
+	Interval time.Duration
+	// How long changes must settle before expanding again, defaults to 250ms.
+	Debounce time.Duration
+	// The stream used to report a summary of the changes to the expanded resources.
+	Log io.Writer
+
+	// The contents of the previous expansion, indexed by resource identifier.
+	rendered map[string]string
+	// The files written to the directory by the previous expansion.
+	written map[string]struct{}
+}
+
+// Watch expands the resources and continues to expand them as the local files
+// change until the context is done. Once there are local files to watch, errors
+// expanding resources are reported to the log instead of being returned.
+func (w *Watcher) Watch(ctx context.Context) error {
+	// Remote resources are cached between expansions, even if caching was not requested
+	if w.Filter.CacheDirectory == "" {
+		dir, err := os.MkdirTemp("", "konjure-watch-")
+		if err != nil {
+			return err
+		}
+		defer os.RemoveAll(dir)
+		w.Filter.CacheDirectory = dir
+	}
+
+	interval := w.Interval
+	if interval <= 0 {
+		interval = 500 * time.Millisecond
+	}
+	debounce := w.Debounce
+	if debounce <= 0 {
+		debounce = 250 * time.Millisecond
+	}
+
+	files, err := w.render(ctx)
+	if err != nil {
+		if len(files) == 0 {
+			return err
+		}
+		w.logf("error: %v\n", err)
+	}
+	if len(files) == 0 {
+		return fmt.Errorf("no local files to watch")
+	}
+
+	state := statFiles(files)
+	ticker := time.NewTicker(interval)
+	defer ticker.Stop()
+
+	var changed time.Time
+	for {
+		select {
+		case <-ctx.Done():
+			return nil
+
+		case now := <-ticker.C:
+			// Wait for a burst of changes to settle before expanding again
+			if current := statFiles(files); !sameFiles(current, state) {
+				state, changed = current, now
+				continue
+			}
+			if changed.IsZero() || now.Sub(changed) < debounce {
+				continue
+			}
+			changed = time.Time{}
+
+			previous := state
+			more, err := w.render(ctx)
+			if err != nil {
+				w.logf("error: %v\n", err)
+				more = append(more, files...)
+			}
+			files = more
+
+			// Keep the state from before the expansion so changes made during it are not missed
+			state = statFiles(files)
+			for name := range state {
+				if fs, ok := previous[name]; ok {
+					state[name] = fs
+				}
+			}
+		}
+	}
+}
+
+// render expands and writes the resources, returning the local files that were read.
+func (w *Watcher) render(ctx context.Context) ([]string, error) {
+	f := w.Filter
+	f.Tracker = &Tracker{}
+	if f.Context == nil {
+		f.Context = ctx
+	}
+
+	nodes, err := w.Resources.Read()
+	if err != nil {
+		return nil, err
+	}
+	nodes, err = f.Filter(nodes)
+	if err != nil {
+		return f.Tracker.Files(), err
+	}
+
+	rendered := make(map[string]string, len(nodes))
+	for _, node := range nodes {
+		rendered[resourceID(node)] = node.MustString()
+	}
+
+	if w.Directory != "" {
+		err = w.writeDirectory(nodes)
+	} else {
+		ww := w.Writer
+		ww.InitialDocumentStart = ww.InitialDocumentStart || w.rendered != nil
+		err = ww.Write(nodes)
+	}
+	if err != nil {
+		return f.Tracker.Files(), err
+	}
+
+	w.logSummary(rendered)
+	w.rendered = rendered
+	return f.Tracker.Files(), nil
+}
+
+// writeDirectory writes each resource to a separate YAML file in the directory,
+// removing any files left over from the previous expansion.
+func (w *Watcher) writeDirectory(nodes []*yaml.RNode) error {
+	if err := os.MkdirAll(w.Directory, 0755); err != nil {
+		return err
+	}
+
+	written := make(map[string]struct{}, len(nodes))
+	for _, node := range nodes {
+		var buf bytes.Buffer
+		ww := w.Writer
+		ww.Format = "yaml"
+		ww.Writer = &buf
+		ww.InitialDocumentStart = false
+		if err := ww.Write([]*yaml.RNode{node}); err != nil {
+			return err
+		}
+
+		name := filepath.Join(w.Directory, resourceFileName(node))
+		written[name] = struct{}{}
+		if data, err := os.ReadFile(name); err == nil && bytes.Equal(data, buf.Bytes()) {
+			continue
+		}
+		if err := os.WriteFile(name, buf.Bytes(), 0644); err != nil {
+			return err
+		}
+	}
+
+	for name := range w.written {
+		if _, ok := written[name]; !ok {
+			if err := os.Remove(name); err != nil && !os.IsNotExist(err) {
+				return err
+			}
+		}
+	}
+
+	w.written = written
+	return nil
+}
+
+// logSummary reports the differences between the previous and current expansions.
+func (w *Watcher) logSummary(rendered map[string]string) {
+	if w.rendered == nil {
+		w.logf("rendered %d resources\n", len(rendered))
+		return
+	}
+
+	var lines []string
+	var added, modified, removed int
+	for id, data := range rendered {
+		switch previous, ok := w.rendered[id]; {
+		case !ok:
+			added++
+			lines = append(lines, "  + "+id)
+		case previous != data:
+			modified++
+			lines = append(lines, "  ~ "+id)
+		}
+	}
+	for id := range w.rendered {
+		if _, ok := rendered[id]; !ok {
+			removed++
+			lines = append(lines, "  - "+id)
+		}
+	}
+	sort.Slice(lines, func(i, j int) bool { return lines[i][4:] < lines[j][4:] })
+
+	w.logf("rendered %d resources (%d added, %d modified, %d removed)\n", len(rendered), added, modified, removed)
+	for _, line := range lines {
+		w.logf("%s\n", line)
+	}
+}
+
+func (w *Watcher) logf(format string, args ...interface{}) {
+	if w.Log != nil {
+		_, _ = fmt.Fprintf(w.Log, format, args...)
+	}
+}
+
+// resourceID returns a display identifier for a resource.
+func resourceID(node *yaml.RNode) string {
+	id := strings.ToLower(node.GetKind()) + "/" + node.GetName()
+	if ns := node.GetNamespace(); ns != "" {
+		id = ns + "/" + id
+	}
+	return id
+}
+
+// resourceFileName returns the file name used when writing a resource to a directory.
+func resourceFileName(node *yaml.RNode) string {
+	var parts []string
+	if ns := node.GetNamespace(); ns != "" {
+		parts = append(parts, ns)
+	}
+	parts = append(parts, strings.Split(node.GetApiVersion(), "/")...)
+	parts = append(parts, node.GetKind(), node.GetName())
+	return strings.ToLower(strings.Join(parts, "_")) + ".yaml"
+}
+
+// fileState is the subset of file information used to detect changes.
+type fileState struct {
+	modTime int64
+	size    int64
+	mode    os.FileMode
+}
+
+// statFiles returns the current state of the files; missing files have a zero state.
+func statFiles(files []string) map[string]fileState {
+	result := make(map[string]fileState, len(files))
+	for _, name := range files {
+		var fs fileState
+		if info, err := os.Stat(name); err == nil {
+			fs = fileState{modTime: info.ModTime().UnixNano(), size: info.Size(), mode: info.Mode()}
+		}
+		result[name] = fs
+	}
+	return result
+}
+
+func sameFiles(a, b map[string]fileState) bool {
+	if len(a) != len(b) {
+		return false
+	}
+	for name, fs := range a {
+		if other, ok := b[name]; !ok || other != fs {
+			return false
+		}
+	}
+	return true
+}
@@ -0,0 +1,87 @@
+/*
+Copyright 2023 GramLabs, Inc.
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package konjure
+
+import (
+	"bytes"
+	"context"
+	"os"
+	"path/filepath"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func TestWatcher_Watch(t *testing.T) {
+	dir := t.TempDir()
+	src := filepath.Join(dir, "src")
+	out := filepath.Join(dir, "out")
+	require.NoError(t, os.Mkdir(src, 0755))
+	writeConfigMap := func(name, value string) {
+		data := "apiVersion: v1\nkind: ConfigMap\nmetadata:\n  name: " + name + "\ndata:\n  value: " + value + "\n"
+		require.NoError(t, os.WriteFile(filepath.Join(src, name+".yaml"), []byte(data), 0644))
+	}
+	readOutput := func(name string) string {
+		data, _ := os.ReadFile(filepath.Join(out, "v1_configmap_"+name+".yaml"))
+		return string(data)
+	}
+
+	writeConfigMap("a", "one")
+
+	log := &bytes.Buffer{}
+	w := &Watcher{
+		Filter:    Filter{Depth: 100},
+		Resources: Resources{NewResource(src)},
+		Directory: out,
+		Interval:  10 * time.Millisecond,
+		Debounce:  20 * time.Millisecond,
+		Log:       log,
+	}
+
+	ctx, cancel := context.WithCancel(context.Background())
+	done := make(chan error)
+	go func() { done <- w.Watch(ctx) }()
+
+	assert.Eventually(t, func() bool { return readOutput("a") != "" }, 5*time.Second, 10*time.Millisecond)
+
+	// Modify an existing file
+	writeConfigMap("a", "two")
+	assert.Eventually(t, func() bool { return strings.Contains(readOutput("a"), "two") }, 5*time.Second, 10*time.Millisecond)
+
+	// Add a new file and remove the original
+	writeConfigMap("b", "three")
+	require.NoError(t, os.Remove(filepath.Join(src, "a.yaml")))
+	assert.Eventually(t, func() bool { return readOutput("a") == "" && readOutput("b") != "" }, 5*time.Second, 10*time.Millisecond)
+
+	cancel()
+	assert.NoError(t, <-done)
+	assert.Contains(t, log.String(), "rendered 1 resources\n")
+	assert.Contains(t, log.String(), "  ~ configmap/a\n")
+	assert.Contains(t, log.String(), "  + configmap/b\n")
+	assert.Contains(t, log.String(), "  - configmap/a\n")
+}
+
+func TestWatcher_Watch_NoFiles(t *testing.T) {
+	w := &Watcher{
+		Filter:    Filter{Depth: 100, DefaultReader: &bytes.Buffer{}},
+		Resources: Resources{NewResource("-")},
+	}
+	assert.EqualError(t, w.Watch(context.Background()), "no local files to watch")
+}
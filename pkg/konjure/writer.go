@@ -31,6 +31,7 @@ import (
 	"text/tabwriter"
 	"text/template"
 
+	"github.com/thestormforge/konjure/internal/readers"
 	"github.com/thestormforge/konjure/pkg/filters"
 	"sigs.k8s.io/kustomize/kyaml/kio"
 	"sigs.k8s.io/kustomize/kyaml/kio/kioutil"
@@ -77,6 +78,12 @@ func (w *Writer) Write(nodes []*yaml.RNode) error {
 		nonKube = meta.Kind == ""
 	}
 
+	// The provenance annotations are only stripped if we are not keeping reader annotations
+	clearAnnotations := w.ClearAnnotations
+	if !w.KeepReaderAnnotations {
+		clearAnnotations = append(clearAnnotations, readers.ProvenanceAnnotation)
+	}
+
 	// Determine the effective format and template
 	f, t := strings.ToLower(w.Format), w.Template
 	if pos := strings.IndexRune(f, '=') + 1; pos > 0 {
@@ -95,7 +102,7 @@ func (w *Writer) Write(nodes []*yaml.RNode) error {
 		ww = &kio.ByteWriter{
 			Writer:                w.Writer,
 			KeepReaderAnnotations: w.KeepReaderAnnotations,
-			ClearAnnotations:      w.ClearAnnotations,
+			ClearAnnotations:      clearAnnotations,
 			Sort:                  w.Sort,
 		}
 
@@ -110,7 +117,7 @@ func (w *Writer) Write(nodes []*yaml.RNode) error {
 		ww = &JSONWriter{
 			Writer:                w.Writer,
 			KeepReaderAnnotations: w.KeepReaderAnnotations,
-			ClearAnnotations:      w.ClearAnnotations,
+			ClearAnnotations:      clearAnnotations,
 			WrappingAPIVersion:    "v1",
 			WrappingKind:          "List",
 			Sort:                  w.Sort,
@@ -126,7 +133,7 @@ func (w *Writer) Write(nodes []*yaml.RNode) error {
 		ww = &JSONWriter{
 			Writer:                w.Writer,
 			KeepReaderAnnotations: w.KeepReaderAnnotations,
-			ClearAnnotations:      w.ClearAnnotations,
+			ClearAnnotations:      clearAnnotations,
 			Sort:                  w.Sort,
 		}
 
@@ -165,6 +172,11 @@ func (w *Writer) Write(nodes []*yaml.RNode) error {
 				"\n{{ end }}{{ else }}No results.\n{{ end }}",
 		}
 
+	case "provenance":
+		ww = &ProvenanceWriter{
+			Writer: tabwriter.NewWriter(w.Writer, 3, 0, 3, ' ', 0),
+		}
+
 	case "csv":
 		headers, paths := splitColumns(t)
 		columns := make([][]string, 0, len(paths))
@@ -328,6 +340,47 @@ func (w *CSVWriter) Write(nodes []*yaml.RNode) error {
 	return cw.Error()
 }
 
+// ProvenanceWriter is a writer which emits a table describing where each resource came from.
+type ProvenanceWriter struct {
+	Writer io.Writer
+}
+
+// Write outputs one row for each origin recorded on each of the supplied nodes.
+func (w *ProvenanceWriter) Write(nodes []*yaml.RNode) error {
+	if _, err := fmt.Fprintln(w.Writer, "RESOURCE\tKIND\tSOURCE\tREF\tFILE\tINDEX"); err != nil {
+		return err
+	}
+
+	for _, n := range nodes {
+		origins, err := readers.GetProvenance(n)
+		if err != nil {
+			return err
+		}
+
+		// Resources which were not expanded are still listed
+		if len(origins) == 0 {
+			origins = append(origins, readers.Origin{})
+		}
+
+		// Only the first origin is labeled with the resource name
+		name := strings.ToLower(n.GetKind()) + "/" + n.GetName()
+		for _, o := range origins {
+			if _, err := fmt.Fprintf(w.Writer, "%s\t%s\t%s\t%s\t%s\t%s\n", name, o.Kind, o.Source, o.Ref, o.File, o.Index); err != nil {
+				return err
+			}
+			name = ""
+		}
+	}
+
+	if f, ok := w.Writer.(interface{ Flush() error }); ok {
+		if err := f.Flush(); err != nil {
+			return err
+		}
+	}
+
+	return nil
+}
+
 // EnvWriter is a writer which only emits name/value pairs found in the data of config maps and secrets.
 type EnvWriter struct {
 	Writer      io.Writer